@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// DefaultListFields is the field mask used for listings when none is given.
+const DefaultListFields = "nextPageToken, files(id, name, mimeType, size, modifiedTime, quotaBytesUsed, parents)"
+
+// ListOptions controls a single page of a file listing. Setting DriveId
+// restricts the listing to that shared drive; otherwise Corpora selects the
+// bodies of items to search ("user" when empty, or "allDrives").
+type ListOptions struct {
+	Query     string
+	OrderBy   string
+	PageSize  int64
+	PageToken string
+	Fields    string
+	DriveId   string
+	Corpora   string
+}
+
+// DriveClient is the subset of the Drive API used by the browser, so the
+// TUI can run against Google Drive or any other backend.
+type DriveClient interface {
+	// List returns one page of files matching opts.Query.
+	List(ctx context.Context, opts ListOptions) (*drive.FileList, error)
+	// ListChildren returns one page of the files inside folderId.
+	ListChildren(ctx context.Context, folderId string, opts ListOptions) (*drive.FileList, error)
+	// GetFile returns the metadata of a single file.
+	GetFile(ctx context.Context, id string, fields string) (*drive.File, error)
+	// Download returns the raw content of a binary file.
+	Download(ctx context.Context, id string) (io.ReadCloser, error)
+	// Export returns the content of a Google Workspace file converted to mimeType.
+	Export(ctx context.Context, id string, mimeType string) (io.ReadCloser, error)
+	// About returns the signed in user.
+	About(ctx context.Context) (*drive.User, error)
+	// ListDrives returns one page of the shared drives the user can access.
+	ListDrives(ctx context.Context, pageToken string) (*drive.DriveList, error)
+}
+
+func childrenQuery(folderId string, query string) string {
+	return And(InParents(folderId), query)
+}
+
+// IsNotFound reports whether err means that a file does not exist or the
+// user cannot see it.
+func IsNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusNotFound
+	}
+	return errors.Is(err, ErrNotFound)
+}
@@ -0,0 +1,250 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/drive/v3"
+)
+
+const folderMimeType = "application/vnd.google-apps.folder"
+
+// FakeRootId is the id of My Drive in a FakeClient. As with Drive, "root"
+// is accepted as an alias for it, but files report the real id.
+const FakeRootId = "fake-root"
+
+// ErrNotFound is returned by FakeClient for unknown file ids.
+var ErrNotFound = errors.New("file not found")
+
+type fakeEntry struct {
+	file    *drive.File
+	content []byte
+}
+
+// FakeClient is an in-memory DriveClient holding a small file tree. My
+// Drive always exists. Shared drives are folders whose id is also
+// their DriveId.
+type FakeClient struct {
+	mu      sync.Mutex
+	user    *drive.User
+	entries map[string]*fakeEntry
+	order   []string
+	drives  []*drive.Drive
+	nextId  int
+}
+
+func NewFakeClient(user *drive.User) *FakeClient {
+	c := &FakeClient{
+		user:    user,
+		entries: map[string]*fakeEntry{},
+	}
+	c.entries[FakeRootId] = &fakeEntry{file: &drive.File{
+		Id:       FakeRootId,
+		Name:     "My Drive",
+		MimeType: folderMimeType,
+	}}
+	return c
+}
+
+// AddFile stores f (and content for binary files) in the tree. An id is
+// generated when f.Id is empty and returned.
+func (c *FakeClient) AddFile(f *drive.File, content []byte) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if f.Id == "" {
+		c.nextId++
+		f.Id = fmt.Sprintf("fake-%d", c.nextId)
+	}
+	if len(f.Parents) == 0 {
+		f.Parents = []string{FakeRootId}
+	}
+	for i, parent := range f.Parents {
+		f.Parents[i] = fakeId(parent)
+	}
+	if parent, ok := c.entries[f.Parents[0]]; ok && f.DriveId == "" {
+		f.DriveId = parent.file.DriveId
+	}
+	if len(f.Owners) == 0 && f.DriveId == "" && c.user != nil {
+		f.Owners = []*drive.User{c.user}
+	}
+	if f.Size == 0 && content != nil {
+		f.Size = int64(len(content))
+	}
+	if _, exists := c.entries[f.Id]; !exists {
+		c.order = append(c.order, f.Id)
+	}
+	c.entries[f.Id] = &fakeEntry{file: f, content: content}
+
+	return f.Id
+}
+
+// AddFolder creates a folder named name inside parentId and returns its id.
+func (c *FakeClient) AddFolder(parentId string, name string) string {
+	return c.AddFile(&drive.File{
+		Name:     name,
+		MimeType: folderMimeType,
+		Parents:  []string{parentId},
+	}, nil)
+}
+
+// AddDrive creates a shared drive named name and returns its id, which is
+// also the id of its root folder.
+func (c *FakeClient) AddDrive(name string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextId++
+	id := fmt.Sprintf("drive-%d", c.nextId)
+	c.drives = append(c.drives, &drive.Drive{Id: id, Name: name})
+	c.entries[id] = &fakeEntry{file: &drive.File{
+		Id:       id,
+		Name:     name,
+		MimeType: folderMimeType,
+		DriveId:  id,
+	}}
+
+	return id
+}
+
+func (c *FakeClient) List(ctx context.Context, opts ListOptions) (*drive.FileList, error) {
+	match, err := parseFakeQuery(opts.Query, c.user)
+	if err != nil {
+		return nil, err
+	}
+	less, err := parseFakeOrder(opts.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	var found []*fakeEntry
+	for _, id := range c.order {
+		e := c.entries[id]
+		if !inCorpora(e, opts) {
+			continue
+		}
+		if match(e) {
+			found = append(found, e)
+		}
+	}
+	c.mu.Unlock()
+
+	sortFakeEntries(found, less)
+
+	start := 0
+	if opts.PageToken != "" {
+		start, err = strconv.Atoi(opts.PageToken)
+		if err != nil || start < 0 || start > len(found) {
+			return nil, fmt.Errorf("invalid page token %q", opts.PageToken)
+		}
+	}
+	size := int(opts.PageSize)
+	if size <= 0 {
+		size = 100
+	}
+	end := min(start+size, len(found))
+
+	res := &drive.FileList{Files: []*drive.File{}}
+	for _, e := range found[start:end] {
+		copied := *e.file
+		res.Files = append(res.Files, &copied)
+	}
+	if end < len(found) {
+		res.NextPageToken = strconv.Itoa(end)
+	}
+
+	return res, nil
+}
+
+// fakeId resolves the "root" alias.
+func fakeId(id string) string {
+	if id == "root" {
+		return FakeRootId
+	}
+	return id
+}
+
+func inCorpora(e *fakeEntry, opts ListOptions) bool {
+	if opts.DriveId != "" {
+		return e.file.DriveId == opts.DriveId
+	}
+	if opts.Corpora == "allDrives" {
+		return true
+	}
+	return e.file.DriveId == ""
+}
+
+func (c *FakeClient) ListChildren(ctx context.Context, folderId string, opts ListOptions) (*drive.FileList, error) {
+	opts.Query = childrenQuery(folderId, opts.Query)
+	return c.List(ctx, opts)
+}
+
+func (c *FakeClient) GetFile(ctx context.Context, id string, fields string) (*drive.File, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[fakeId(id)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	copied := *e.file
+	return &copied, nil
+}
+
+func (c *FakeClient) Download(ctx context.Context, id string) (io.ReadCloser, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[fakeId(id)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if strings.HasPrefix(e.file.MimeType, "application/vnd.google-apps.") {
+		return nil, fmt.Errorf("only files with binary content can be downloaded: %s", id)
+	}
+	return io.NopCloser(bytes.NewReader(e.content)), nil
+}
+
+func (c *FakeClient) Export(ctx context.Context, id string, mimeType string) (io.ReadCloser, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[fakeId(id)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if !strings.HasPrefix(e.file.MimeType, "application/vnd.google-apps.") || e.file.MimeType == folderMimeType {
+		return nil, fmt.Errorf("export only supports Docs Editors files: %s", id)
+	}
+	return io.NopCloser(bytes.NewReader(e.content)), nil
+}
+
+func (c *FakeClient) ListDrives(ctx context.Context, pageToken string) (*drive.DriveList, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if pageToken != "" {
+		return nil, fmt.Errorf("invalid page token %q", pageToken)
+	}
+	res := &drive.DriveList{Drives: []*drive.Drive{}}
+	for _, d := range c.drives {
+		copied := *d
+		res.Drives = append(res.Drives, &copied)
+	}
+	return res, nil
+}
+
+func (c *FakeClient) About(ctx context.Context) (*drive.User, error) {
+	if c.user == nil {
+		return &drive.User{}, nil
+	}
+	copied := *c.user
+	return &copied, nil
+}
@@ -0,0 +1,467 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// This file implements just enough of the Drive query language
+// (https://developers.google.com/drive/api/guides/ref-search-terms) and
+// orderBy syntax for FakeClient.
+
+type fakeMatcher func(e *fakeEntry) bool
+
+type fakeToken struct {
+	kind  string // "str", "word", "op", "(", ")"
+	value string
+}
+
+func tokenizeFakeQuery(q string) ([]fakeToken, error) {
+	var tokens []fakeToken
+	runes := []rune(q)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, fakeToken{kind: string(r)})
+			i++
+		case r == '\'':
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == '\'' {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("invalid query: unterminated string")
+			}
+			tokens = append(tokens, fakeToken{kind: "str", value: sb.String()})
+		case strings.ContainsRune("=!<>", r):
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				op += "="
+				i++
+			}
+			i++
+			if op == "!" {
+				return nil, fmt.Errorf("invalid query: unexpected '!'")
+			}
+			tokens = append(tokens, fakeToken{kind: "op", value: op})
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || strings.ContainsRune("_-.", runes[i])) {
+				i++
+			}
+			tokens = append(tokens, fakeToken{kind: "word", value: string(runes[start:i])})
+		default:
+			return nil, fmt.Errorf("invalid query: unexpected %q", r)
+		}
+	}
+
+	return tokens, nil
+}
+
+type fakeQueryParser struct {
+	tokens []fakeToken
+	pos    int
+	user   *drive.User
+}
+
+func parseFakeQuery(q string, user *drive.User) (fakeMatcher, error) {
+	if strings.TrimSpace(q) == "" {
+		return func(e *fakeEntry) bool { return !isFakeRoot(e) }, nil
+	}
+
+	tokens, err := tokenizeFakeQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &fakeQueryParser{tokens: tokens, user: user}
+	m, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("invalid query: unexpected %q", p.tokens[p.pos].value)
+	}
+
+	return func(e *fakeEntry) bool { return !isFakeRoot(e) && m(e) }, nil
+}
+
+// isFakeRoot reports whether e is My Drive or the root of a shared drive,
+// which never show up in listings.
+func isFakeRoot(e *fakeEntry) bool {
+	return e.file.Id == FakeRootId || e.file.Id == e.file.DriveId
+}
+
+func (p *fakeQueryParser) peek() (fakeToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return fakeToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *fakeQueryParser) next() (fakeToken, error) {
+	t, ok := p.peek()
+	if !ok {
+		return t, fmt.Errorf("invalid query: unexpected end")
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *fakeQueryParser) isKeyword(word string) bool {
+	t, ok := p.peek()
+	return ok && t.kind == "word" && strings.EqualFold(t.value, word)
+}
+
+func (p *fakeQueryParser) parseOr() (fakeMatcher, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e *fakeEntry) bool { return l(e) || right(e) }
+	}
+	return left, nil
+}
+
+func (p *fakeQueryParser) parseAnd() (fakeMatcher, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(e *fakeEntry) bool { return l(e) && right(e) }
+	}
+	return left, nil
+}
+
+func (p *fakeQueryParser) parseUnary() (fakeMatcher, error) {
+	if p.isKeyword("not") {
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(e *fakeEntry) bool { return !inner(e) }, nil
+	}
+
+	if t, ok := p.peek(); ok && t.kind == "(" {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t, err := p.next(); err != nil || t.kind != ")" {
+			return nil, fmt.Errorf("invalid query: missing ')'")
+		}
+		return inner, nil
+	}
+
+	return p.parseTerm()
+}
+
+func (p *fakeQueryParser) parseTerm() (fakeMatcher, error) {
+	first, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	// 'value' in collection
+	if first.kind == "str" {
+		if !p.isKeyword("in") {
+			return nil, fmt.Errorf("invalid query: expected 'in' after %q", first.value)
+		}
+		p.pos++
+		collection, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		return p.inMatcher(first.value, collection.value)
+	}
+
+	if first.kind != "word" {
+		return nil, fmt.Errorf("invalid query: unexpected %q", first.kind)
+	}
+
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if op.kind == "word" && strings.EqualFold(op.value, "contains") {
+		op = fakeToken{kind: "op", value: "contains"}
+	}
+	if op.kind != "op" {
+		return nil, fmt.Errorf("invalid query: expected operator after %s", first.value)
+	}
+
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	return fakeFieldMatcher(first.value, op.value, value)
+}
+
+func (p *fakeQueryParser) inMatcher(value string, collection string) (fakeMatcher, error) {
+	switch collection {
+	case "parents":
+		value = fakeId(value)
+		return func(e *fakeEntry) bool {
+			for _, parent := range e.file.Parents {
+				if parent == value {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "owners", "writers", "readers":
+		if value == "me" && p.user != nil {
+			value = p.user.EmailAddress
+		}
+		return func(e *fakeEntry) bool {
+			if len(e.file.Owners) == 0 {
+				return p.user != nil && value == p.user.EmailAddress
+			}
+			for _, owner := range e.file.Owners {
+				if strings.EqualFold(owner.EmailAddress, value) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	return nil, fmt.Errorf("invalid query: unknown collection %q", collection)
+}
+
+func fakeFieldMatcher(field string, op string, value fakeToken) (fakeMatcher, error) {
+	switch field {
+	case "name", "mimeType":
+		if value.kind != "str" {
+			return nil, fmt.Errorf("invalid query: %s expects a string", field)
+		}
+		get := func(e *fakeEntry) string { return e.file.Name }
+		if field == "mimeType" {
+			get = func(e *fakeEntry) string { return e.file.MimeType }
+		}
+		return stringMatcher(get, op, value.value)
+
+	case "fullText":
+		if op != "contains" || value.kind != "str" {
+			return nil, fmt.Errorf("invalid query: fullText only supports contains")
+		}
+		needle := strings.ToLower(value.value)
+		return func(e *fakeEntry) bool {
+			return strings.Contains(strings.ToLower(e.file.Name), needle) ||
+				strings.Contains(strings.ToLower(e.file.Description), needle) ||
+				strings.Contains(strings.ToLower(string(e.content)), needle)
+		}, nil
+
+	case "trashed", "starred", "sharedWithMe":
+		if op != "=" && op != "!=" {
+			return nil, fmt.Errorf("invalid query: %s only supports = and !=", field)
+		}
+		want, err := strconv.ParseBool(value.value)
+		if err != nil || value.kind != "word" {
+			return nil, fmt.Errorf("invalid query: %s expects true or false", field)
+		}
+		get := func(e *fakeEntry) bool {
+			switch field {
+			case "trashed":
+				return e.file.Trashed
+			case "starred":
+				return e.file.Starred
+			}
+			return e.file.SharedWithMeTime != ""
+		}
+		return func(e *fakeEntry) bool { return (get(e) == want) == (op == "=") }, nil
+
+	case "modifiedTime", "createdTime", "viewedByMeTime", "sharedWithMeTime":
+		if value.kind != "str" || op == "contains" {
+			return nil, fmt.Errorf("invalid query: %s expects a date comparison", field)
+		}
+		want, err := parseFakeTime(value.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query: bad date %q", value.value)
+		}
+		return func(e *fakeEntry) bool {
+			have, err := parseFakeTime(fakeTimeField(e.file, field))
+			if err != nil {
+				return false
+			}
+			return compareOrdered(have.Compare(want), op)
+		}, nil
+	}
+
+	return nil, fmt.Errorf("invalid query: unknown field %q", field)
+}
+
+func stringMatcher(get func(e *fakeEntry) string, op string, value string) (fakeMatcher, error) {
+	switch op {
+	case "contains":
+		needle := strings.ToLower(value)
+		return func(e *fakeEntry) bool { return strings.Contains(strings.ToLower(get(e)), needle) }, nil
+	case "=":
+		return func(e *fakeEntry) bool { return get(e) == value }, nil
+	case "!=":
+		return func(e *fakeEntry) bool { return get(e) != value }, nil
+	}
+	return nil, fmt.Errorf("invalid query: operator %s not supported for strings", op)
+}
+
+func compareOrdered(cmp int, op string) bool {
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+func parseFakeTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func fakeTimeField(f *drive.File, field string) string {
+	switch field {
+	case "modifiedTime":
+		return f.ModifiedTime
+	case "createdTime":
+		return f.CreatedTime
+	case "viewedByMeTime":
+		return f.ViewedByMeTime
+	case "sharedWithMeTime":
+		return f.SharedWithMeTime
+	case "modifiedByMeTime":
+		return f.ModifiedByMeTime
+	}
+	return ""
+}
+
+type fakeLess func(a, b *drive.File) int
+
+func parseFakeOrder(orderBy string) (fakeLess, error) {
+	var keys []fakeLess
+
+	for _, part := range strings.Split(orderBy, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		desc := len(fields) > 1 && fields[1] == "desc"
+
+		var cmp fakeLess
+		switch fields[0] {
+		case "name", "name_natural":
+			cmp = func(a, b *drive.File) int {
+				return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+			}
+		case "folder":
+			cmp = func(a, b *drive.File) int {
+				return boolRank(a.MimeType == folderMimeType) - boolRank(b.MimeType == folderMimeType)
+			}
+		case "starred":
+			cmp = func(a, b *drive.File) int { return boolRank(a.Starred) - boolRank(b.Starred) }
+		case "quotaBytesUsed":
+			cmp = func(a, b *drive.File) int { return compareInt(a.QuotaBytesUsed, b.QuotaBytesUsed) }
+		case "modifiedTime", "createdTime", "viewedByMeTime", "sharedWithMeTime", "modifiedByMeTime", "recency":
+			field := fields[0]
+			if field == "recency" {
+				field = "modifiedTime"
+			}
+			cmp = func(a, b *drive.File) int {
+				return strings.Compare(fakeTimeField(a, field), fakeTimeField(b, field))
+			}
+		default:
+			return nil, fmt.Errorf("invalid orderBy: unknown key %q", fields[0])
+		}
+
+		// Folders and starred items come first when sorted ascending.
+		if fields[0] == "folder" || fields[0] == "starred" {
+			desc = !desc
+		}
+		if desc {
+			asc := cmp
+			cmp = func(a, b *drive.File) int { return -asc(a, b) }
+		}
+		keys = append(keys, cmp)
+	}
+
+	return func(a, b *drive.File) int {
+		for _, cmp := range keys {
+			if c := cmp(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}, nil
+}
+
+func sortFakeEntries(entries []*fakeEntry, less fakeLess) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return less(entries[i].file, entries[j].file) < 0
+	})
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,85 @@
+package fakeserver
+
+import (
+	"fmt"
+
+	"drivebrowser/client"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// SampleDrive returns a FakeClient populated with a small, deterministic
+// tree that is useful for trying the browser out.
+func SampleDrive() *client.FakeClient {
+	fc := client.NewFakeClient(&drive.User{
+		DisplayName:  "Test User",
+		EmailAddress: "test.user@example.com",
+	})
+
+	work := fc.AddFolder("root", "Work")
+	reports := fc.AddFolder(work, "Reports")
+	year := fc.AddFolder(reports, "2026")
+	personal := fc.AddFolder("root", "Personal")
+	photos := fc.AddFolder(personal, "Photos")
+
+	for i := 1; i <= 12; i++ {
+		fc.AddFile(&drive.File{
+			Name:         fmt.Sprintf("report-2026-%02d.txt", i),
+			MimeType:     "text/plain",
+			Parents:      []string{year},
+			ModifiedTime: fmt.Sprintf("2026-%02d-01T09:00:00Z", i),
+			CreatedTime:  fmt.Sprintf("2026-%02d-01T09:00:00Z", i),
+		}, []byte(fmt.Sprintf("Quarterly figures for month %d\n", i)))
+	}
+	for i := 1; i <= 40; i++ {
+		fc.AddFile(&drive.File{
+			Name:         fmt.Sprintf("IMG_%04d.jpg", i),
+			MimeType:     "image/jpeg",
+			Parents:      []string{photos},
+			ModifiedTime: fmt.Sprintf("2025-07-%02dT12:00:00Z", i%28+1),
+		}, make([]byte, 1024*i))
+	}
+
+	fc.AddFile(&drive.File{
+		Name:         "Roadmap",
+		MimeType:     "application/vnd.google-apps.document",
+		Parents:      []string{work},
+		Starred:      true,
+		ModifiedTime: "2026-03-14T15:09:26Z",
+		Description:  "Plans for the year",
+	}, []byte("Roadmap: ship the quarterly review"))
+	fc.AddFile(&drive.File{
+		Name:         "Budget",
+		MimeType:     "application/vnd.google-apps.spreadsheet",
+		Parents:      []string{work},
+		ModifiedTime: "2026-02-02T10:00:00Z",
+	}, []byte("budget,1000\n"))
+	fc.AddFile(&drive.File{
+		Name:         "O'Brien's notes.md",
+		MimeType:     "text/markdown",
+		ModifiedTime: "2026-01-20T08:30:00Z",
+	}, []byte("# Notes\n"))
+	team := fc.AddDrive("Team Projects")
+	launch := fc.AddFolder(team, "Launch")
+	fc.AddFile(&drive.File{
+		Name:         "launch-plan.txt",
+		MimeType:     "text/plain",
+		Parents:      []string{launch},
+		ModifiedTime: "2026-04-01T12:00:00Z",
+	}, []byte("Launch checklist and quarterly targets\n"))
+	fc.AddFile(&drive.File{
+		Name:         "Team charter",
+		MimeType:     "application/vnd.google-apps.document",
+		Parents:      []string{team},
+		ModifiedTime: "2026-01-05T12:00:00Z",
+	}, []byte("Who we are"))
+
+	fc.AddFile(&drive.File{
+		Name:         "old-draft.txt",
+		MimeType:     "text/plain",
+		Trashed:      true,
+		ModifiedTime: "2025-11-11T11:11:11Z",
+	}, []byte("draft"))
+
+	return fc
+}
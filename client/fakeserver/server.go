@@ -0,0 +1,183 @@
+// Package fakeserver serves a FakeClient tree over the Drive v3 REST API so
+// the whole program can be run against a local stand-in for googleapis.com.
+package fakeserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"drivebrowser/client"
+)
+
+// BasePath is the path prefix the Drive v3 client library expects.
+const BasePath = "/drive/v3/"
+
+// Server is a running fake Drive API backed by Drive.
+type Server struct {
+	*httptest.Server
+	Drive *client.FakeClient
+}
+
+// NewServer starts a fake Drive API on a random local port. Call Close when
+// done.
+func NewServer(fc *client.FakeClient) *Server {
+	return &Server{
+		Server: httptest.NewServer(Handler(fc)),
+		Drive:  fc,
+	}
+}
+
+// Endpoint returns the value to pass to option.WithEndpoint.
+func (s *Server) Endpoint() string {
+	return s.URL + BasePath
+}
+
+// Handler returns an http.Handler implementing files.list, files.get
+// (including alt=media), files.export, drives.list and about.get on top of
+// fc.
+func Handler(fc *client.FakeClient) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(BasePath, "/"))
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+
+		switch {
+		case len(parts) == 1 && parts[0] == "about":
+			handleAbout(fc, w, r)
+		case len(parts) == 1 && parts[0] == "drives":
+			handleDrives(fc, w, r)
+		case len(parts) == 1 && parts[0] == "files":
+			handleList(fc, w, r)
+		case len(parts) == 2 && parts[0] == "files":
+			handleGet(fc, w, r, parts[1])
+		case len(parts) == 3 && parts[0] == "files" && parts[2] == "export":
+			handleExport(fc, w, r, parts[1])
+		default:
+			writeError(w, http.StatusNotFound, "unknown endpoint "+r.URL.Path)
+		}
+	})
+}
+
+func handleAbout(fc *client.FakeClient, w http.ResponseWriter, r *http.Request) {
+	user, err := fc.About(r.Context())
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	writeJSON(w, map[string]any{"kind": "drive#about", "user": user})
+}
+
+func handleList(fc *client.FakeClient, w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	opts := client.ListOptions{
+		Query:     params.Get("q"),
+		OrderBy:   params.Get("orderBy"),
+		PageToken: params.Get("pageToken"),
+		DriveId:   params.Get("driveId"),
+		Corpora:   params.Get("corpora"),
+	}
+	if opts.Corpora == "drive" && opts.DriveId == "" {
+		writeError(w, http.StatusBadRequest, "driveId is required with corpora=drive")
+		return
+	}
+	if size := params.Get("pageSize"); size != "" {
+		n, err := strconv.ParseInt(size, 10, 64)
+		if err != nil || n < 1 || n > 1000 {
+			writeError(w, http.StatusBadRequest, "invalid pageSize "+size)
+			return
+		}
+		opts.PageSize = n
+	}
+
+	res, err := fc.List(r.Context(), opts)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	res.Kind = "drive#fileList"
+	writeJSON(w, res)
+}
+
+func handleDrives(fc *client.FakeClient, w http.ResponseWriter, r *http.Request) {
+	res, err := fc.ListDrives(r.Context(), r.URL.Query().Get("pageToken"))
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	res.Kind = "drive#driveList"
+	writeJSON(w, res)
+}
+
+func handleGet(fc *client.FakeClient, w http.ResponseWriter, r *http.Request, id string) {
+	if r.URL.Query().Get("alt") == "media" {
+		body, err := fc.Download(r.Context(), id)
+		if err != nil {
+			writeClientError(w, err)
+			return
+		}
+		defer body.Close()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		io.Copy(w, body)
+		return
+	}
+
+	f, err := fc.GetFile(r.Context(), id, r.URL.Query().Get("fields"))
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	f.Kind = "drive#file"
+	writeJSON(w, f)
+}
+
+func handleExport(fc *client.FakeClient, w http.ResponseWriter, r *http.Request, id string) {
+	mimeType := r.URL.Query().Get("mimeType")
+	if mimeType == "" {
+		writeError(w, http.StatusBadRequest, "mimeType is required")
+		return
+	}
+
+	body, err := fc.Export(r.Context(), id, mimeType)
+	if err != nil {
+		writeClientError(w, err)
+		return
+	}
+	defer body.Close()
+	w.Header().Set("Content-Type", mimeType)
+	io.Copy(w, body)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeClientError(w http.ResponseWriter, err error) {
+	if errors.Is(err, client.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeError(w, http.StatusBadRequest, err.Error())
+}
+
+// writeError responds in the JSON error format understood by googleapi.
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,172 @@
+package fakeserver
+
+import (
+	"context"
+	"io"
+	"slices"
+	"testing"
+
+	"drivebrowser/client"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+// newTestClient serves SampleDrive and returns a GoogleClient talking to it
+// over HTTP, as main does with -endpoint.
+func newTestClient(t *testing.T) *client.GoogleClient {
+	t.Helper()
+	s := NewServer(SampleDrive())
+	t.Cleanup(s.Close)
+
+	srv, err := drive.NewService(context.Background(),
+		option.WithEndpoint(s.Endpoint()), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.NewGoogleClient(srv)
+}
+
+func names(files []*drive.File) []string {
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func TestAbout(t *testing.T) {
+	c := newTestClient(t)
+
+	user, err := c.About(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.EmailAddress != "test.user@example.com" {
+		t.Errorf("About().EmailAddress = %q", user.EmailAddress)
+	}
+}
+
+func TestListChildren(t *testing.T) {
+	c := newTestClient(t)
+
+	res, err := c.ListChildren(context.Background(), "root", client.ListOptions{
+		Query:   client.Is("trashed", false),
+		OrderBy: "folder, name",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Personal", "Work", "O'Brien's notes.md"}
+	if got := names(res.Files); !slices.Equal(got, want) {
+		t.Errorf("ListChildren(root) = %q, want %q", got, want)
+	}
+}
+
+func TestListQuotedName(t *testing.T) {
+	c := newTestClient(t)
+
+	res, err := c.List(context.Background(), client.ListOptions{
+		Query: client.Compare("name", "=", "O'Brien's notes.md"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(res.Files); !slices.Equal(got, []string{"O'Brien's notes.md"}) {
+		t.Errorf("List(name = O'Brien's notes.md) = %q", got)
+	}
+}
+
+func TestListPages(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	opts := client.ListOptions{
+		Query:    client.Compare("mimeType", "=", "image/jpeg"),
+		OrderBy:  "name",
+		PageSize: 15,
+	}
+
+	var got []string
+	for pages := 1; ; pages++ {
+		res, err := c.List(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, names(res.Files)...)
+		if res.NextPageToken == "" {
+			if pages != 3 {
+				t.Errorf("got %d pages, want 3", pages)
+			}
+			break
+		}
+		opts.PageToken = res.NextPageToken
+	}
+	if len(got) != 40 || got[0] != "IMG_0001.jpg" || got[39] != "IMG_0040.jpg" {
+		t.Errorf("listed %d photos from %q to %q", len(got), got[0], got[len(got)-1])
+	}
+}
+
+func TestSharedDrive(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	drives, err := c.ListDrives(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drives.Drives) != 1 || drives.Drives[0].Name != "Team Projects" {
+		t.Fatalf("ListDrives() = %v", drives.Drives)
+	}
+
+	id := drives.Drives[0].Id
+	res, err := c.ListChildren(ctx, id, client.ListOptions{DriveId: id, OrderBy: "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(res.Files); !slices.Equal(got, []string{"Launch", "Team charter"}) {
+		t.Errorf("ListChildren(%s) = %q", id, got)
+	}
+}
+
+func TestGetAndDownload(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	res, err := c.List(ctx, client.ListOptions{Query: client.Compare("name", "=", "report-2026-03.txt")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Files) != 1 {
+		t.Fatalf("found %d files named report-2026-03.txt", len(res.Files))
+	}
+
+	f, err := c.GetFile(ctx, res.Files[0].Id, "id, name, parents")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "report-2026-03.txt" || len(f.Parents) != 1 {
+		t.Errorf("GetFile() = %+v", f)
+	}
+
+	body, err := c.Download(ctx, f.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	content, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "Quarterly figures for month 3\n" {
+		t.Errorf("Download() = %q", content)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	c := newTestClient(t)
+
+	_, err := c.GetFile(context.Background(), "missing", "id")
+	if !client.IsNotFound(err) {
+		t.Errorf("GetFile(missing) error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// GoogleClient implements DriveClient on top of the Drive v3 API.
+type GoogleClient struct {
+	srv *drive.Service
+}
+
+func NewGoogleClient(srv *drive.Service) *GoogleClient {
+	return &GoogleClient{srv: srv}
+}
+
+func (c *GoogleClient) List(ctx context.Context, opts ListOptions) (*drive.FileList, error) {
+	fields := opts.Fields
+	if fields == "" {
+		fields = DefaultListFields
+	}
+
+	call := c.srv.Files.List().Context(ctx).Fields(googleapi.Field(fields)).
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true)
+	if opts.DriveId != "" {
+		call = call.Corpora("drive").DriveId(opts.DriveId)
+	} else if opts.Corpora != "" {
+		call = call.Corpora(opts.Corpora)
+	}
+	if opts.Query != "" {
+		call = call.Q(opts.Query)
+	}
+	if opts.OrderBy != "" {
+		call = call.OrderBy(opts.OrderBy)
+	}
+	if opts.PageSize > 0 {
+		call = call.PageSize(opts.PageSize)
+	}
+	if opts.PageToken != "" {
+		call = call.PageToken(opts.PageToken)
+	}
+
+	return call.Do()
+}
+
+func (c *GoogleClient) ListChildren(ctx context.Context, folderId string, opts ListOptions) (*drive.FileList, error) {
+	opts.Query = childrenQuery(folderId, opts.Query)
+	return c.List(ctx, opts)
+}
+
+func (c *GoogleClient) GetFile(ctx context.Context, id string, fields string) (*drive.File, error) {
+	return c.srv.Files.Get(id).Context(ctx).SupportsAllDrives(true).Fields(googleapi.Field(fields)).Do()
+}
+
+func (c *GoogleClient) Download(ctx context.Context, id string) (io.ReadCloser, error) {
+	resp, err := c.srv.Files.Get(id).Context(ctx).SupportsAllDrives(true).Download()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *GoogleClient) Export(ctx context.Context, id string, mimeType string) (io.ReadCloser, error) {
+	resp, err := c.srv.Files.Export(id, mimeType).Context(ctx).Download()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *GoogleClient) About(ctx context.Context) (*drive.User, error) {
+	about, err := c.srv.About.Get().Fields("user(displayName, emailAddress)").Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	return about.User, nil
+}
+
+func (c *GoogleClient) ListDrives(ctx context.Context, pageToken string) (*drive.DriveList, error) {
+	call := c.srv.Drives.List().Context(ctx).PageSize(100).Fields("nextPageToken, drives(id, name)")
+	if pageToken != "" {
+		call = call.PageToken(pageToken)
+	}
+	return call.Do()
+}
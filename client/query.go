@@ -0,0 +1,80 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Helpers for building Drive search queries (the q parameter of List).
+// Values are always written with Quote, so user input can never end a
+// string literal early.
+
+var literalEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// Quote returns s as a query string literal.
+func Quote(s string) string {
+	return "'" + literalEscaper.Replace(s) + "'"
+}
+
+// Compare returns the clause field op 'value', e.g. name = 'Reports' or
+// modifiedTime > '2026-01-01T00:00:00'.
+func Compare(field string, op string, value string) string {
+	return field + " " + op + " " + Quote(value)
+}
+
+// Contains returns the clause field contains 'value'.
+func Contains(field string, value string) string {
+	return Compare(field, "contains", value)
+}
+
+// In returns the clause 'value' in field, for the collection fields such
+// as parents and owners.
+func In(value string, field string) string {
+	return Quote(value) + " in " + field
+}
+
+// InParents returns the clause matching the children of a folder.
+func InParents(folderId string) string {
+	return In(folderId, "parents")
+}
+
+// Is returns the clause field = true or field = false, for the boolean
+// fields such as trashed and starred.
+func Is(field string, value bool) string {
+	return field + " = " + strconv.FormatBool(value)
+}
+
+// And joins clauses with and, skipping empty ones.
+func And(clauses ...string) string {
+	return join(" and ", " or ", clauses)
+}
+
+// Or joins clauses with or, skipping empty ones.
+func Or(clauses ...string) string {
+	return join(" or ", " and ", clauses)
+}
+
+// Not negates a clause.
+func Not(clause string) string {
+	if strings.Contains(clause, " and ") || strings.Contains(clause, " or ") {
+		clause = "(" + clause + ")"
+	}
+	return "not " + clause
+}
+
+// join parenthesizes the clauses that contain the other operator, so the
+// result does not depend on precedence. Clauses that only contain it inside
+// a literal are parenthesized too, which is harmless.
+func join(op string, other string, clauses []string) string {
+	var parts []string
+	for _, c := range clauses {
+		if c == "" {
+			continue
+		}
+		if strings.Contains(c, other) {
+			c = "(" + c + ")"
+		}
+		parts = append(parts, c)
+	}
+	return strings.Join(parts, op)
+}
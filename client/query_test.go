@@ -0,0 +1,49 @@
+package client
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Reports", `'Reports'`},
+		{"O'Brien", `'O\'Brien'`},
+		{`C:\temp`, `'C:\\temp'`},
+		{`\'`, `'\\\''`},
+		{"", `''`},
+	}
+	for _, tt := range tests {
+		if got := Quote(tt.in); got != tt.want {
+			t.Errorf("Quote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestClauses(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"compare", Compare("name", "=", "O'Brien"), `name = 'O\'Brien'`},
+		{"contains", Contains("fullText", "plan"), `fullText contains 'plan'`},
+		{"in parents", InParents("abc"), `'abc' in parents`},
+		{"is", Is("trashed", false), `trashed = false`},
+		{"and skips empty", And("", Is("starred", true), ""), `starred = true`},
+		{"and", And(InParents("a"), Is("trashed", false)), `'a' in parents and trashed = false`},
+		{"and wraps or", And(Or(InParents("a"), InParents("b")), Is("trashed", false)),
+			`('a' in parents or 'b' in parents) and trashed = false`},
+		{"or wraps and", Or(And(Is("starred", true), Is("trashed", false)), InParents("a")),
+			`(starred = true and trashed = false) or 'a' in parents`},
+		{"not", Not(Is("starred", true)), `not starred = true`},
+		{"not wraps compound", Not(And(Is("starred", true), Is("trashed", false))),
+			`not (starred = true and trashed = false)`},
+		{"empty", And(), ``},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
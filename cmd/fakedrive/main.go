@@ -0,0 +1,24 @@
+// Command fakedrive serves a sample in-memory Drive over HTTP so the
+// browser can be run with -endpoint instead of talking to googleapis.com.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+
+	"drivebrowser/client/fakeserver"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8081", "address to listen on")
+	flag.Parse()
+
+	fmt.Printf("Fake Drive API listening, run the browser with:\n  go run . -endpoint http://%s%s\n", *addr, fakeserver.BasePath)
+
+	handler := fakeserver.Handler(fakeserver.SampleDrive())
+	if err := http.ListenAndServe(*addr, handler); err != nil {
+		log.Fatalf("Unable to serve fake Drive API: %v", err)
+	}
+}
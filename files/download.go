@@ -1,13 +1,16 @@
 package files
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
-	"net/http"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 
-	"google.golang.org/api/drive/v3"
+	"drivebrowser/client"
 )
 
 var mimeTypes = map[string]string{
@@ -17,40 +20,76 @@ var mimeTypes = map[string]string{
 	"application/vnd.google-apps.drawing":      "image/png",
 }
 
-
-
-func DownloadFile(srv *drive.Service, id string) {
-	dFile, err := srv.Files.Get(id).Fields("name, mimeType").Do()
+// DownloadFile saves the file with the given id into the output directory,
+// exporting Google Workspace files to a regular format first. It returns
+// the path that was written.
+func DownloadFile(ctx context.Context, c client.DriveClient, id string) (string, error) {
+	dFile, err := c.GetFile(ctx, id, "name, mimeType")
 	if err != nil {
-		log.Fatal(err.Error())
+		return "", err
 	}
-	var resp *http.Response
-	if k, v := mimeTypes[dFile.MimeType]; v {
-		resp, err = srv.Files.Export(id, k).Download()
-
-		if err != nil {
-			fmt.Println(err.Error())
-		}
 
+	var body io.ReadCloser
+	if k, v := mimeTypes[dFile.MimeType]; v {
+		body, err = c.Export(ctx, id, k)
 	} else {
-		resp, err = srv.Files.Get(id).Download()
-
-		if err != nil {
-			fmt.Println(err.Error())
-		}
-
+		body, err = c.Download(ctx, id)
 	}
-	defer resp.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("downloading %s: %w", dFile.Name, err)
+	}
+	defer body.Close()
+
 	if err := os.MkdirAll("output", 0755); err != nil {
-		log.Fatal(err.Error())
+		return "", err
 	}
 
-	file, err := os.Create("output/" + dFile.Name)
+	file, err := createUnique(filepath.Join("output", localName(dFile.Name)))
 	if err != nil {
-		log.Fatal(err.Error())
+		return "", err
 	}
+	path := file.Name()
 
-	defer file.Close()
+	_, err = io.Copy(file, body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// Leave no partial file behind to be mistaken for the download.
+		os.Remove(path)
+		return "", fmt.Errorf("downloading %s: %w", dFile.Name, err)
+	}
+
+	return path, nil
+}
+
+// localName turns a Drive file name, which may contain slashes or be
+// empty, into a name for a file inside the output directory.
+func localName(name string) string {
+	name = strings.NewReplacer("/", "_", `\`, "_").Replace(name)
+	if name == "" || name == "." || name == ".." {
+		return "untitled"
+	}
+	return name
+}
+
+// maxCopies is the number of numbered names tried before giving up.
+const maxCopies = 1000
 
-	io.Copy(file, resp.Body)
+// createUnique creates the file at path, or at "name (1).ext", "name (2).ext"
+// and so on when it exists, so downloads never overwrite each other. Drive
+// allows several files with the same name in one folder.
+func createUnique(path string) (*os.File, error) {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	candidate := path
+	for i := 1; i <= maxCopies; i++ {
+		file, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if !errors.Is(err, fs.ErrExist) {
+			return file, err
+		}
+		candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+	return nil, fmt.Errorf("too many copies of %s", path)
 }
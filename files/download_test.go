@@ -0,0 +1,130 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"drivebrowser/client"
+
+	"google.golang.org/api/drive/v3"
+)
+
+func TestDownloadDuplicateNames(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	c := client.NewFakeClient(nil)
+	var ids []string
+	for _, content := range []string{"one", "two", "three"} {
+		ids = append(ids, c.AddFile(&drive.File{Name: "notes.txt", MimeType: "text/plain"}, []byte(content)))
+	}
+
+	var wg sync.WaitGroup
+	paths := make([]string, len(ids))
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path, err := DownloadFile(context.Background(), c, id)
+			if err != nil {
+				t.Error(err)
+			}
+			paths[i] = path
+		}()
+	}
+	wg.Wait()
+
+	slices.Sort(paths)
+	want := []string{
+		filepath.Join("output", "notes (1).txt"),
+		filepath.Join("output", "notes (2).txt"),
+		filepath.Join("output", "notes.txt"),
+	}
+	if !slices.Equal(paths, want) {
+		t.Fatalf("downloaded to %q, want %q", paths, want)
+	}
+
+	var contents []string
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(b))
+	}
+	slices.Sort(contents)
+	if !slices.Equal(contents, []string{"one", "three", "two"}) {
+		t.Errorf("downloaded contents %q", contents)
+	}
+}
+
+func TestLocalName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"notes.txt", "notes.txt"},
+		{"Q1/Q2 plan", "Q1_Q2 plan"},
+		{"/", "_"},
+		{`a\b`, "a_b"},
+		{"", "untitled"},
+		{".", "untitled"},
+		{"..", "untitled"},
+		{"../up", ".._up"},
+	}
+	for _, tt := range tests {
+		if got := localName(tt.name); got != tt.want {
+			t.Errorf("localName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// failingClient serves content that breaks off with an error.
+type failingClient struct {
+	*client.FakeClient
+}
+
+func (c failingClient) Download(ctx context.Context, id string) (io.ReadCloser, error) {
+	return io.NopCloser(io.MultiReader(strings.NewReader("partial"), iotest.ErrReader(errors.New("connection reset")))), nil
+}
+
+func TestDownloadFailureLeavesNoFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	c := client.NewFakeClient(nil)
+	id := c.AddFile(&drive.File{Name: "big.bin", MimeType: "application/octet-stream"}, []byte("x"))
+
+	if _, err := DownloadFile(context.Background(), failingClient{c}, id); err == nil {
+		t.Fatal("DownloadFile succeeded with a broken body")
+	}
+	entries, err := os.ReadDir("output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("left %s behind", entries[0].Name())
+	}
+}
+
+func TestDownloadOddNames(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	c := client.NewFakeClient(nil)
+	for _, name := range []string{"/", ".", ""} {
+		id := c.AddFile(&drive.File{Name: name, MimeType: "text/plain"}, []byte("x"))
+		path, err := DownloadFile(context.Background(), c, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Dir(path) != "output" {
+			t.Errorf("%q downloaded to %s, outside output", name, path)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"drivebrowser/client"
+
+	"google.golang.org/api/drive/v3"
+)
+
+const resolveFields = "id, name, mimeType, parents, driveId"
+
+// maxMatches is how many items with the same name are listed when a path
+// is ambiguous.
+const maxMatches = 10
+
+// maxDepth bounds the walk up the parents of a file.
+const maxDepth = 64
+
+// ErrPathNotFound is returned when a path does not lead to a file.
+var ErrPathNotFound = errors.New("no such file or folder")
+
+// ErrNoPath is returned for files that are not below My Drive or a shared
+// drive, e.g. files shared with the user from a folder they cannot see.
+var ErrNoPath = errors.New("not reachable from My Drive or a shared drive")
+
+// AmbiguousPathError reports a path that leads to more than one file,
+// because several items in a folder share a name or a file has several
+// parents.
+type AmbiguousPathError struct {
+	Path    string
+	Matches []string
+}
+
+func (e *AmbiguousPathError) Error() string {
+	return fmt.Sprintf("%s is ambiguous, it matches %d items: %s", e.Path, len(e.Matches), strings.Join(e.Matches, ", "))
+}
+
+// Resolver converts slash separated paths such as /Work/Reports/2026 to
+// Drive ids and back. Paths start at the root of My Drive, or at a shared
+// drive when the first segment is its name. A "/" inside a name is written
+// as "\/" and a backslash as "\\". Lookups are cached for the lifetime of
+// the resolver.
+type Resolver struct {
+	c client.DriveClient
+
+	mu       sync.Mutex
+	rootId   string
+	drives   map[string][]string
+	files    map[string]*drive.File
+	children map[string][]*drive.File
+}
+
+func NewResolver(c client.DriveClient) *Resolver {
+	return &Resolver{
+		c:        c,
+		files:    map[string]*drive.File{},
+		children: map[string][]*drive.File{},
+	}
+}
+
+// Resolve returns the file at path. It returns an *AmbiguousPathError when
+// more than one item matches a segment.
+func (r *Resolver) Resolve(ctx context.Context, path string) (*drive.File, error) {
+	names := SplitPath(path)
+
+	current, err := r.getFile(ctx, "root")
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		matches, err := r.lookup(ctx, current, name)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			drives, err := r.drivesNamed(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			matches = append(matches, drives...)
+		}
+
+		prefix := JoinPath(names[:i+1])
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("%s: %w", prefix, ErrPathNotFound)
+		case 1:
+			current = matches[0]
+		default:
+			ids := make([]string, len(matches))
+			for i, f := range matches {
+				ids[i] = f.Id
+			}
+			return nil, &AmbiguousPathError{Path: prefix, Matches: ids}
+		}
+	}
+	return current, nil
+}
+
+// ResolveId returns the id of the file at path.
+func (r *Resolver) ResolveId(ctx context.Context, path string) (string, error) {
+	f, err := r.Resolve(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	return f.Id, nil
+}
+
+// Path returns the path of the file with the given id. It returns an
+// *AmbiguousPathError when the file has several paths.
+func (r *Resolver) Path(ctx context.Context, id string) (string, error) {
+	paths, err := r.Paths(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if len(paths) > 1 {
+		return "", &AmbiguousPathError{Path: id, Matches: paths}
+	}
+	return paths[0], nil
+}
+
+// Paths returns every path of the file with the given id, one for each
+// chain of parents that leads to a root.
+func (r *Resolver) Paths(ctx context.Context, id string) ([]string, error) {
+	chains, err := r.chains(ctx, id, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, names := range chains {
+		paths = append(paths, JoinPath(names))
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("%s: %w", id, ErrNoPath)
+	}
+	return paths, nil
+}
+
+// chains returns the names from a root down to the file with the given id,
+// for each of its paths.
+func (r *Resolver) chains(ctx context.Context, id string, depth int) ([][]string, error) {
+	f, err := r.getFile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	root, err := r.getFile(ctx, "root")
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case f.Id == root.Id:
+		return [][]string{{}}, nil
+	case f.DriveId != "" && f.Id == f.DriveId:
+		return [][]string{{f.Name}}, nil
+	case depth >= maxDepth:
+		return nil, nil
+	}
+
+	var chains [][]string
+	for _, parentId := range f.Parents {
+		parents, err := r.chains(ctx, parentId, depth+1)
+		if client.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, names := range parents {
+			chains = append(chains, append(names[:len(names):len(names)], f.Name))
+		}
+	}
+	return chains, nil
+}
+
+func (r *Resolver) getFile(ctx context.Context, id string) (*drive.File, error) {
+	r.mu.Lock()
+	if id == "root" && r.rootId != "" {
+		id = r.rootId
+	}
+	f, ok := r.files[id]
+	r.mu.Unlock()
+	if ok {
+		return f, nil
+	}
+
+	f, err := r.c.GetFile(ctx, id, resolveFields)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == "root" {
+		r.rootId = f.Id
+	}
+	r.files[f.Id] = f
+	return f, nil
+}
+
+// lookup returns the items named name in the folder parent.
+func (r *Resolver) lookup(ctx context.Context, parent *drive.File, name string) ([]*drive.File, error) {
+	key := parent.Id + "/" + name
+
+	r.mu.Lock()
+	matches, ok := r.children[key]
+	r.mu.Unlock()
+	if ok {
+		return matches, nil
+	}
+
+	res, err := r.c.List(ctx, client.ListOptions{
+		Query:    client.And(client.InParents(parent.Id), client.Compare("name", "=", name), client.Is("trashed", false)),
+		PageSize: maxMatches,
+		Fields:   "files(" + resolveFields + ")",
+		DriveId:  parent.DriveId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.children[key] = res.Files
+	for _, f := range res.Files {
+		r.files[f.Id] = f
+	}
+	return res.Files, nil
+}
+
+// drivesNamed returns the root folders of the shared drives named name.
+func (r *Resolver) drivesNamed(ctx context.Context, name string) ([]*drive.File, error) {
+	r.mu.Lock()
+	drives := r.drives
+	r.mu.Unlock()
+
+	if drives == nil {
+		drives = map[string][]string{}
+		pageToken := ""
+		for {
+			res, err := r.c.ListDrives(ctx, pageToken)
+			if err != nil {
+				return nil, err
+			}
+			for _, d := range res.Drives {
+				drives[d.Name] = append(drives[d.Name], d.Id)
+			}
+			if res.NextPageToken == "" {
+				break
+			}
+			pageToken = res.NextPageToken
+		}
+
+		r.mu.Lock()
+		r.drives = drives
+		r.mu.Unlock()
+	}
+
+	var roots []*drive.File
+	for _, id := range drives[name] {
+		f, err := r.getFile(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, f)
+	}
+	return roots, nil
+}
+
+// SplitPath splits a path into the names of its segments, undoing the
+// escaping of JoinPath.
+func SplitPath(path string) []string {
+	var names []string
+	var name strings.Builder
+	escaped := false
+	for _, ch := range path {
+		switch {
+		case escaped:
+			name.WriteRune(ch)
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case ch == '/':
+			if name.Len() > 0 {
+				names = append(names, name.String())
+				name.Reset()
+			}
+		default:
+			name.WriteRune(ch)
+		}
+	}
+	if name.Len() > 0 {
+		names = append(names, name.String())
+	}
+	return names
+}
+
+// JoinPath builds a path from the names of its segments.
+func JoinPath(names []string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `/`, `\/`)
+
+	path := ""
+	for _, name := range names {
+		path += "/" + escaper.Replace(name)
+	}
+	if path == "" {
+		return "/"
+	}
+	return path
+}
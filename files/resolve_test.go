@@ -0,0 +1,155 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"drivebrowser/client"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// testDrive is My Drive with
+//
+//	Work/
+//	  Reports/
+//	    shared.txt (also directly in Work)
+//	  a/b
+//	  back\slash
+//	Dup, Dup
+//
+// and a shared drive Team holding Launch/.
+type testDrive struct {
+	*client.FakeClient
+	work, reports, shared, slash, backslash, team, launch string
+	dups                                                  []string
+}
+
+func newTestDrive() *testDrive {
+	c := client.NewFakeClient(nil)
+	d := &testDrive{FakeClient: c}
+	d.work = c.AddFolder("root", "Work")
+	d.reports = c.AddFolder(d.work, "Reports")
+	d.shared = c.AddFile(&drive.File{Name: "shared.txt", MimeType: "text/plain", Parents: []string{d.reports, d.work}}, []byte("s"))
+	d.slash = c.AddFile(&drive.File{Name: "a/b", MimeType: "text/plain", Parents: []string{d.work}}, []byte("x"))
+	d.backslash = c.AddFile(&drive.File{Name: `back\slash`, MimeType: "text/plain", Parents: []string{d.work}}, []byte("x"))
+	d.dups = []string{c.AddFolder("root", "Dup"), c.AddFolder("root", "Dup")}
+	d.team = c.AddDrive("Team")
+	d.launch = c.AddFolder(d.team, "Launch")
+	return d
+}
+
+func TestResolve(t *testing.T) {
+	d := newTestDrive()
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", client.FakeRootId},
+		{"/Work", d.work},
+		{"Work/Reports/", d.reports},
+		{"/Work/Reports/shared.txt", d.shared},
+		{"/Work/shared.txt", d.shared},
+		{`/Work/a\/b`, d.slash},
+		{`/Work/back\\slash`, d.backslash},
+		{"/Team", d.team},
+		{"/Team/Launch", d.launch},
+	}
+	r := NewResolver(d)
+	for _, tt := range tests {
+		got, err := r.ResolveId(context.Background(), tt.path)
+		if err != nil {
+			t.Errorf("ResolveId(%q): %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveId(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	d := newTestDrive()
+	r := NewResolver(d)
+
+	_, err := r.Resolve(context.Background(), "/Dup/inner")
+	var ambiguous *AmbiguousPathError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("Resolve(/Dup/inner) error = %v, want an AmbiguousPathError", err)
+	}
+	if ambiguous.Path != "/Dup" || !slices.Equal(ambiguous.Matches, d.dups) {
+		t.Errorf("ambiguous %s matching %q, want /Dup matching %q", ambiguous.Path, ambiguous.Matches, d.dups)
+	}
+
+	if _, err := r.Resolve(context.Background(), "/Work/Missing"); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("Resolve(/Work/Missing) error = %v, want ErrPathNotFound", err)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	d := newTestDrive()
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{client.FakeRootId, []string{"/"}},
+		{d.reports, []string{"/Work/Reports"}},
+		{d.shared, []string{"/Work/Reports/shared.txt", "/Work/shared.txt"}},
+		{d.slash, []string{`/Work/a\/b`}},
+		{d.launch, []string{"/Team/Launch"}},
+	}
+	r := NewResolver(d)
+	for _, tt := range tests {
+		got, err := r.Paths(context.Background(), tt.id)
+		if err != nil {
+			t.Errorf("Paths(%s): %v", tt.id, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Paths(%s) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+
+	_, err := r.Path(context.Background(), d.shared)
+	var ambiguous *AmbiguousPathError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Matches) != 2 {
+		t.Errorf("Path(shared.txt) error = %v, want an AmbiguousPathError with 2 paths", err)
+	}
+}
+
+func TestPathsUnreachable(t *testing.T) {
+	c := client.NewFakeClient(nil)
+	orphan := c.AddFile(&drive.File{Name: "orphan.txt", MimeType: "text/plain", Parents: []string{"gone"}}, []byte("x"))
+
+	if _, err := NewResolver(c).Paths(context.Background(), orphan); !errors.Is(err, ErrNoPath) {
+		t.Errorf("Paths(orphan) error = %v, want ErrNoPath", err)
+	}
+}
+
+func TestSplitJoinPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		names []string
+	}{
+		{"/", nil},
+		{"/Work/Reports", []string{"Work", "Reports"}},
+		{`/Q1\/Q2/plan`, []string{"Q1/Q2", "plan"}},
+		{`/C:\\temp`, []string{`C:\temp`}},
+		{`/a\\\/b`, []string{`a\/b`}},
+	}
+	for _, tt := range tests {
+		names := SplitPath(tt.path)
+		if !slices.Equal(names, tt.names) {
+			t.Errorf("SplitPath(%q) = %q, want %q", tt.path, names, tt.names)
+		}
+		if path := JoinPath(names); path != tt.path {
+			t.Errorf("JoinPath(%q) = %q, want %q", names, path, tt.path)
+		}
+	}
+
+	if got := SplitPath("Work//Reports/"); !slices.Equal(got, []string{"Work", "Reports"}) {
+		t.Errorf("SplitPath skips empty segments, got %q", got)
+	}
+}
@@ -0,0 +1,36 @@
+package files
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// driveIdPath matches the id in the paths of Drive and Docs links, e.g.
+// /drive/folders/ID, /drive/u/0/folders/ID, /file/d/ID/view and
+// /document/d/ID/edit.
+var driveIdPath = regexp.MustCompile(`/(?:folders|d)/([A-Za-z0-9_-]+)`)
+
+// IdFromURL extracts the file or folder id from a link to
+// drive.google.com or docs.google.com.
+func IdFromURL(raw string) (string, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid Drive URL %q: %w", raw, err)
+	}
+	if u.Host != "drive.google.com" && u.Host != "docs.google.com" {
+		return "", fmt.Errorf("not a Google Drive URL: %q", raw)
+	}
+
+	if m := driveIdPath.FindStringSubmatch(u.Path); m != nil {
+		return m[1], nil
+	}
+	if id := u.Query().Get("id"); id != "" {
+		return id, nil
+	}
+	return "", fmt.Errorf("no file or folder id in URL %q", raw)
+}
@@ -0,0 +1,43 @@
+package files
+
+import "testing"
+
+func TestIdFromURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://drive.google.com/drive/folders/1AbC_d-9", "1AbC_d-9"},
+		{"https://drive.google.com/drive/u/0/folders/1AbC?usp=sharing", "1AbC"},
+		{"https://drive.google.com/file/d/1XyZ/view?usp=drive_link", "1XyZ"},
+		{"https://docs.google.com/document/d/1Doc/edit#heading=h.1", "1Doc"},
+		{"https://docs.google.com/spreadsheets/d/1Sheet/edit", "1Sheet"},
+		{"https://drive.google.com/open?id=1Open", "1Open"},
+		{"drive.google.com/drive/folders/1NoScheme", "1NoScheme"},
+	}
+	for _, tt := range tests {
+		got, err := IdFromURL(tt.url)
+		if err != nil {
+			t.Errorf("IdFromURL(%q): %v", tt.url, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("IdFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestIdFromURLErrors(t *testing.T) {
+	for _, url := range []string{
+		"https://example.com/drive/folders/1AbC",
+		"https://drive.google.com.evil.example/drive/folders/1AbC",
+		"https://google.com/open?id=1AbC",
+		"https://drive.google.com/drive/my-drive",
+		"https://drive.google.com/open",
+		"https://drive.google.com/%zz",
+	} {
+		if id, err := IdFromURL(url); err == nil {
+			t.Errorf("IdFromURL(%q) = %q, want an error", url, id)
+		}
+	}
+}
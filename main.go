@@ -19,8 +19,11 @@ package main
 
 import (
 	"context"
+	"drivebrowser/client"
+	"drivebrowser/files"
 	"drivebrowser/token"
 	"drivebrowser/tui"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -33,27 +36,31 @@ import (
 )
 
 func main() {
-	ctx := context.Background()
-	b, err := os.ReadFile("./credentials.json")
-	if err != nil {
-		log.Fatalf("Unable to read client secret file: %v", err)
-	}
+	endpoint := flag.String("endpoint", "", "Drive API endpoint to use instead of googleapis.com, e.g. a local fake server (requests are sent without OAuth credentials)")
+	folderId := flag.String("folder", "", "id of the folder (or file) to start in")
+	path := flag.String("path", "", "slash separated path below My Drive to start in, e.g. /Work/Reports/2026")
+	link := flag.String("url", "", "drive.google.com link to the folder or file to start in")
+	flag.Parse()
 
-	// If modifying these scopes, delete your previously saved token.json.
-	config, err := google.ConfigFromJSON(b, drive.DriveReadonlyScope)
-	if err != nil {
-		log.Fatalf("Unable to parse client secret file to config: %v", err)
+	if startFlags(*folderId, *path, *link) > 1 {
+		log.Fatalf("Only one of -folder, -path and -url can be given")
 	}
-	client := token.GetClient(config)
 
-	srv, err := drive.NewService(ctx, option.WithHTTPClient(client))
+	ctx := context.Background()
+
+	srv, err := newDriveService(ctx, *endpoint)
 	if err != nil {
 		log.Fatalf("Unable to retrieve Drive client: %v", err)
 	}
 
-	currDir := "root"
+	c := client.NewGoogleClient(srv)
+
+	currDir, err := startId(ctx, c, *folderId, *path, *link)
+	if err != nil {
+		log.Fatalf("Unable to find the starting folder: %v", err)
+	}
 
-	p := tea.NewProgram(tui.InitialModel(ctx, srv, currDir))
+	p := tea.NewProgram(tui.InitialModel(ctx, c, currDir), tea.WithMouseCellMotion())
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
 		os.Exit(1)
@@ -61,4 +68,49 @@ func main() {
 
 }
 
+// startFlags counts the flags choosing where to start that were given.
+func startFlags(values ...string) int {
+	n := 0
+	for _, v := range values {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// startId returns the id of the folder or file to start in from whichever
+// of the flags was given, defaulting to the root of My Drive.
+func startId(ctx context.Context, c client.DriveClient, folderId string, path string, link string) (string, error) {
+	switch {
+	case folderId != "":
+		return folderId, nil
+	case path != "":
+		return files.NewResolver(c).ResolveId(ctx, path)
+	case link != "":
+		return files.IdFromURL(link)
+	}
+	return "root", nil
+}
+
+func newDriveService(ctx context.Context, endpoint string) (*drive.Service, error) {
+	if endpoint != "" {
+		return drive.NewService(ctx, option.WithEndpoint(endpoint), option.WithoutAuthentication())
+	}
+
+	b, err := os.ReadFile("./credentials.json")
+	if err != nil {
+		log.Fatalf("Unable to read client secret file: %v", err)
+	}
+
+	// If modifying these scopes, delete your previously saved token.json.
+	config, err := google.ConfigFromJSON(b, drive.DriveReadonlyScope)
+	if err != nil {
+		log.Fatalf("Unable to parse client secret file to config: %v", err)
+	}
+	httpClient := token.GetClient(config)
+
+	return drive.NewService(ctx, option.WithHTTPClient(httpClient))
+}
+
 // [END drive_quickstart]
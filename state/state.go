@@ -0,0 +1,110 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// maxHistory is the number of past searches kept.
+const maxHistory = 100
+
+// Search is a query typed at the search prompt, with the folder it was
+// typed in for queries that refer to the current folder.
+type Search struct {
+	Name     string `json:"name,omitempty"`
+	Query    string `json:"query"`
+	Content  bool   `json:"content,omitempty"`
+	FolderId string `json:"folderId,omitempty"`
+	DriveId  string `json:"driveId,omitempty"`
+}
+
+// State is what the browser remembers between runs for one account: the
+// searches run recently, oldest first, and the searches saved by name.
+type State struct {
+	path    string
+	History []Search `json:"history"`
+	Saved   []Search `json:"saved"`
+}
+
+// Path is the state file of the account with the given email address.
+func Path(account string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if account == "" {
+		account = "default"
+	}
+	return filepath.Join(dir, "drive-browser", filepath.Base(account)+".json"), nil
+}
+
+// Load reads the state of an account. An account without a state file
+// starts out empty.
+func Load(account string) (*State, error) {
+	path, err := Path(account)
+	if err != nil {
+		return &State{}, err
+	}
+	s := &State{path: path}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// Save writes the state back to its file.
+func (s *State) Save() error {
+	if s.path == "" {
+		return errors.New("no state file")
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	// Replace the file in one step so a crash cannot leave half of it.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// AddHistory records a search as the most recent one, dropping an earlier
+// run of the same query.
+func (s *State) AddHistory(search Search) {
+	search.Name = ""
+	s.History = slices.DeleteFunc(s.History, func(h Search) bool {
+		return h.Query == search.Query && h.Content == search.Content
+	})
+	s.History = append(s.History, search)
+	if len(s.History) > maxHistory {
+		s.History = s.History[len(s.History)-maxHistory:]
+	}
+}
+
+// SaveSearch stores a named search, replacing any saved under that name.
+func (s *State) SaveSearch(search Search) {
+	for i, saved := range s.Saved {
+		if saved.Name == search.Name {
+			s.Saved[i] = search
+			return
+		}
+	}
+	s.Saved = append(s.Saved, search)
+}
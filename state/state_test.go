@@ -0,0 +1,106 @@
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func queries(searches []Search) []string {
+	var queries []string
+	for _, s := range searches {
+		queries = append(queries, s.Query)
+	}
+	return queries
+}
+
+func TestLoadMissing(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	s, err := Load("t@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.History) != 0 || len(s.Saved) != 0 {
+		t.Errorf("new state = %+v, want empty", s)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	s, err := Load("t@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AddHistory(Search{Query: "report"})
+	s.SaveSearch(Search{Name: "Plans", Query: "plan in:below", FolderId: "f1"})
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load("t@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(loaded.History, s.History) || !slices.Equal(loaded.Saved, s.Saved) {
+		t.Errorf("loaded %+v, saved %+v", loaded, s)
+	}
+
+	other, err := Load("someone@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(other.History) != 0 {
+		t.Errorf("another account sees history %q", queries(other.History))
+	}
+}
+
+func TestPathStaysInStateDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	for _, account := range []string{"t@example.com", "../../escape", ""} {
+		path, err := Path(account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Dir(path) != filepath.Join(dir, "drive-browser") {
+			t.Errorf("Path(%q) = %s, outside the state directory", account, path)
+		}
+	}
+}
+
+func TestAddHistory(t *testing.T) {
+	var s State
+	s.AddHistory(Search{Query: "a"})
+	s.AddHistory(Search{Query: "b"})
+	s.AddHistory(Search{Query: "a", Content: true})
+	s.AddHistory(Search{Query: "a", Name: "ignored"})
+
+	if got := queries(s.History); !slices.Equal(got, []string{"b", "a", "a"}) {
+		t.Errorf("history %q, want the repeated a moved to the end", got)
+	}
+	if last := s.History[len(s.History)-1]; last.Name != "" || last.Content {
+		t.Errorf("last entry %+v", last)
+	}
+
+	for i := range maxHistory + 5 {
+		s.AddHistory(Search{Query: fmt.Sprint(i)})
+	}
+	if len(s.History) != maxHistory || s.History[0].Query != "5" {
+		t.Errorf("kept %d searches starting at %q", len(s.History), s.History[0].Query)
+	}
+}
+
+func TestSaveSearchReplacesByName(t *testing.T) {
+	var s State
+	s.SaveSearch(Search{Name: "Plans", Query: "plan"})
+	s.SaveSearch(Search{Name: "Photos", Query: "type:image"})
+	s.SaveSearch(Search{Name: "Plans", Query: "plan in:below"})
+
+	if got := queries(s.Saved); !slices.Equal(got, []string{"plan in:below", "type:image"}) {
+		t.Errorf("saved %q", got)
+	}
+}
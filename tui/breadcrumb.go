@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"context"
+
+	"drivebrowser/client"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// crumbFields are the fields needed to place a folder in the breadcrumb.
+const crumbFields = "id, name, driveId, parents"
+
+// maxCrumbs bounds the walk up the parents chain.
+const maxCrumbs = 64
+
+// crumb is one folder of the breadcrumb. Virtual locations such as Starred
+// have no id.
+type crumb struct {
+	id      string
+	name    string
+	driveId string
+}
+
+func crumbOf(f *drive.File) crumb {
+	return crumb{id: f.Id, name: f.Name, driveId: f.DriveId}
+}
+
+// folderPath returns the breadcrumb of folder f by walking up its parents
+// until it reaches a folder in known, the root of a drive or a parent the
+// user cannot access.
+func folderPath(ctx context.Context, c client.DriveClient, f *drive.File, known []crumb) []crumb {
+	chain := []crumb{crumbOf(f)}
+	var prefix []crumb
+
+	for len(f.Parents) > 0 && len(chain) < maxCrumbs {
+		parentId := f.Parents[0]
+		if i := crumbIndex(known, parentId); i >= 0 {
+			prefix = known[:i+1]
+			break
+		}
+
+		parent, err := c.GetFile(ctx, parentId, crumbFields)
+		if err != nil {
+			break
+		}
+		chain = append(chain, crumbOf(parent))
+		f = parent
+	}
+
+	path := make([]crumb, 0, len(prefix)+len(chain))
+	path = append(path, prefix...)
+	for i := len(chain) - 1; i >= 0; i-- {
+		path = append(path, chain[i])
+	}
+	return path
+}
+
+func crumbIndex(path []crumb, id string) int {
+	for i, c := range path {
+		if c.id != "" && c.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func crumbNames(path []crumb) []string {
+	names := make([]string, len(path))
+	for i, c := range path {
+		names[i] = c.name
+	}
+	return names
+}
+
+// ancestorListings returns unloaded listings for the folders above the
+// last one in path, each with the next folder down as its only entry so the
+// cursor lands on it once the listing is loaded.
+func ancestorListings(path []crumb) []listing {
+	stack := []listing{}
+	for i := 0; i+1 < len(path); i++ {
+		l := folderListing(path[i].id, path[i].name, path[i].driveId)
+		l.path = path[:i+1]
+		l.files = []*drive.File{{
+			Id:       path[i+1].id,
+			Name:     path[i+1].name,
+			MimeType: "application/vnd.google-apps.folder",
+		}}
+		stack = append(stack, l)
+	}
+	return stack
+}
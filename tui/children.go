@@ -0,0 +1,89 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/api/drive/v3"
+)
+
+// folderChildren caches the children of a folder that is previewed or
+// expanded without being opened. Pages are added as they are requested.
+type folderChildren struct {
+	listing
+	err error
+}
+
+type childrenLoadedMsg struct {
+	id    string
+	token string
+	res   *drive.FileList
+	err   error
+}
+
+// FetchChildren loads the first page of children of folder f into the
+// children cache, unless it is already there.
+func (m *gModel) FetchChildren(f *drive.File) tea.Cmd {
+	if f == nil || !isFolder(f) {
+		return nil
+	}
+	if _, ok := m.children[f.Id]; ok {
+		return nil
+	}
+
+	l := folderListing(f.Id, f.Name, f.DriveId)
+	if m.activeListing().trashed {
+		l = l.inTrash()
+	}
+	l.sort = m.sort
+	m.children[f.Id] = &folderChildren{listing: l}
+
+	return m.fetchChildren(f.Id)
+}
+
+// FetchMoreChildren loads the next page of children of a cached folder.
+func (m *gModel) FetchMoreChildren(id string) tea.Cmd {
+	entry, ok := m.children[id]
+	if !ok || entry.fetching || entry.err != nil || !entry.hasMore() {
+		return nil
+	}
+	return m.fetchChildren(id)
+}
+
+func (m *gModel) fetchChildren(id string) tea.Cmd {
+	entry := m.children[id]
+	entry.fetching = true
+
+	ctx, c, opts := m.ctx, m.client, entry.options()
+	return func() tea.Msg {
+		res, err := c.List(ctx, opts)
+		return childrenLoadedMsg{id: id, token: opts.PageToken, res: res, err: err}
+	}
+}
+
+func (m *gModel) ShowChildren(msg childrenLoadedMsg) {
+	entry, ok := m.children[msg.id]
+	if !ok || entry.nextPageToken != msg.token {
+		return
+	}
+	if msg.err != nil {
+		entry.fetching = false
+		entry.err = msg.err
+		return
+	}
+	entry.addPage(msg.res)
+}
+
+// cursorOn returns the index of the child with the given id, or -1 when
+// it has not been loaded.
+func (c *folderChildren) cursorOn(id string) int {
+	for i, f := range c.files {
+		if f.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// loaded reports whether the first page has arrived.
+func (c *folderChildren) loaded() bool {
+	return c.err != nil || c.files != nil || !c.fetching
+}
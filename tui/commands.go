@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"context"
+
+	"drivebrowser/client"
+	"drivebrowser/files"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/api/drive/v3"
+)
+
+// Results of the asynchronous Drive requests started from Update. seq
+// identifies the navigation request so that results which were superseded
+// while they were in flight can be dropped; pages are matched to their
+// listing by id instead, since they never replace what is on screen.
+
+type folderLoadedMsg struct {
+	seq     int
+	listing listing
+}
+
+type pageLoadedMsg struct {
+	listingId int
+	token     string
+	res       *drive.FileList
+	err       error
+}
+
+type listingReloadedMsg struct {
+	seq       int
+	listingId int
+	listing   listing
+}
+
+type searchLoadedMsg struct {
+	seq     int
+	listing listing
+}
+
+type downloadedMsg struct {
+	path string
+	err  error
+}
+
+type errMsg struct {
+	seq int
+	err error
+}
+
+// startRequest invalidates any navigation request still in flight and
+// returns the sequence number for a new one.
+func (m *gModel) startRequest() int {
+	m.requestSeq++
+	m.loading = true
+	return m.requestSeq
+}
+
+// cancelPending drops the result of any navigation request still in flight.
+func (m *gModel) cancelPending() {
+	m.requestSeq++
+	m.loading = false
+}
+
+// maxDownloads is the number of files downloaded at the same time.
+const maxDownloads = 4
+
+// downloadSlots limits the downloads running at once; each download holds
+// a slot while it runs.
+var downloadSlots = make(chan struct{}, maxDownloads)
+
+func (m *gModel) DownloadFile(f *drive.File) tea.Cmd {
+	ctx, c := m.ctx, m.client
+	m.downloads++
+
+	return func() tea.Msg {
+		downloadSlots <- struct{}{}
+		defer func() { <-downloadSlots }()
+
+		path, err := files.DownloadFile(ctx, c, f.Id)
+		return downloadedMsg{path: path, err: err}
+	}
+}
+
+// finishRequest reports whether seq is the navigation request the model is
+// waiting for, and if so marks it as done.
+func (m *gModel) finishRequest(seq int) bool {
+	if seq != m.requestSeq {
+		return false
+	}
+	m.loading = false
+	return true
+}
+
+func listCmd(ctx context.Context, c client.DriveClient, seq int, opts client.ListOptions, done func(*drive.FileList) tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		res, err := c.List(ctx, opts)
+		if err != nil {
+			return errMsg{seq: seq, err: err}
+		}
+		return done(res)
+	}
+}
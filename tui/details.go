@@ -0,0 +1,186 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	lipgloss "github.com/charmbracelet/lipgloss"
+	"google.golang.org/api/drive/v3"
+)
+
+const detailFields = "id, name, mimeType, size, quotaBytesUsed, description, " +
+	"owners(displayName, emailAddress), lastModifyingUser(displayName, emailAddress), " +
+	"createdTime, modifiedTime, shared, starred, trashed, webViewLink"
+
+const (
+	detailsWidth      = 42
+	detailsHeight     = 11
+	detailsSideMinCol = 90
+)
+
+// fileDetails is a cache entry for the metadata and paths of one file.
+// All fields are nil while the request is in flight.
+type fileDetails struct {
+	file  *drive.File
+	paths []string
+	err   error
+}
+
+type detailsLoadedMsg struct {
+	id    string
+	file  *drive.File
+	paths []string
+	err   error
+}
+
+// FetchDetails requests the full metadata of the highlighted file when the
+// details pane is open and it has not been fetched yet.
+func (m *gModel) FetchDetails() tea.Cmd {
+	if !(m.showDetails || m.millerMode) || m.locationPicker != nil {
+		return nil
+	}
+	f := m.selectedFile()
+	if f == nil {
+		return nil
+	}
+	if _, ok := m.details[f.Id]; ok {
+		return nil
+	}
+	m.details[f.Id] = &fileDetails{}
+
+	ctx, c, r, id := m.ctx, m.client, m.resolver, f.Id
+	return func() tea.Msg {
+		file, err := c.GetFile(ctx, id, detailFields)
+		if err != nil {
+			return detailsLoadedMsg{id: id, err: err}
+		}
+		// Files outside My Drive and the shared drives have no path.
+		paths, _ := r.Paths(ctx, id)
+		return detailsLoadedMsg{id: id, file: file, paths: paths}
+	}
+}
+
+// detailsBeside reports whether the details pane fits next to the list
+// rather than below it.
+func (m *gModel) detailsBeside() bool {
+	return m.width >= detailsSideMinCol
+}
+
+func (m gModel) renderDetails() string {
+	style := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#FFFFFF")).
+		Border(lipgloss.RoundedBorder(), true).
+		Padding(0, 1).
+		Width(detailsWidth)
+	if !m.detailsBeside() {
+		style = style.Width(max(detailsWidth, m.width-4)).Height(detailsHeight - 2)
+	}
+
+	f := m.selectedFile()
+	if f == nil || m.locationPicker != nil {
+		return style.Render("No file selected")
+	}
+
+	d := m.details[f.Id]
+	switch {
+	case d == nil || (d.file == nil && d.err == nil):
+		return style.Render(f.Name + "\n\nLoading details...")
+	case d.err != nil:
+		return style.Render(f.Name + "\n\nError: " + d.err.Error())
+	}
+
+	return style.Render(formatDetails(d.file, d.paths))
+}
+
+func formatDetails(f *drive.File, paths []string) string {
+	label := lipgloss.NewStyle().Foreground(lipgloss.Color("#9AA0A6"))
+	var lines []string
+	add := func(name, value string) {
+		if value != "" {
+			lines = append(lines, label.Render(fmt.Sprintf("%-10s", name))+value)
+		}
+	}
+
+	lines = append(lines, lipgloss.NewStyle().Bold(true).Render(f.Name), "")
+	add("Path", strings.Join(paths, ", "))
+	add("Type", formatMimeType(f.MimeType))
+	if !isFolder(f) {
+		add("Size", formatSize(f.Size, f.QuotaBytesUsed))
+	}
+	add("Owner", formatUsers(f.Owners))
+	if f.LastModifyingUser != nil {
+		add("Modifier", formatUsers([]*drive.User{f.LastModifyingUser}))
+	}
+	add("Modified", formatTime(f.ModifiedTime))
+	add("Created", formatTime(f.CreatedTime))
+
+	sharing := "Private"
+	if f.Shared {
+		sharing = "Shared"
+	}
+	if f.Starred {
+		sharing += ", starred"
+	}
+	if f.Trashed {
+		sharing += ", in trash"
+	}
+	add("Sharing", sharing)
+	add("Link", f.WebViewLink)
+
+	if f.Description != "" {
+		lines = append(lines, "", f.Description)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func formatMimeType(mimeType string) string {
+	if kind, ok := strings.CutPrefix(mimeType, "application/vnd.google-apps."); ok {
+		return "Google " + kind
+	}
+	return mimeType
+}
+
+func formatUsers(users []*drive.User) string {
+	var names []string
+	for _, u := range users {
+		name := u.DisplayName
+		if name == "" {
+			name = u.EmailAddress
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func formatTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// formatSize formats the size of a file. Google Workspace files have no
+// size of their own, so the storage quota they use is shown instead.
+func formatSize(size int64, quota int64) string {
+	if size == 0 && quota > 0 {
+		return formatBytes(quota) + " (quota)"
+	}
+	return formatBytes(size)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,186 @@
+package tui
+
+import (
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	lipgloss "github.com/charmbracelet/lipgloss"
+)
+
+// filterState is a client-side fuzzy filter over the files already loaded
+// into a listing. It only lasts while that listing is on screen.
+type filterState struct {
+	query    string
+	sourceId int
+	cursor   int
+	listing  listing
+}
+
+var matchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFD75F")).Underline(true)
+
+// StartFilter opens the filter prompt over the active listing.
+func (m *gModel) StartFilter() {
+	source := m.unfilteredListing()
+	m.filter = &filterState{sourceId: source.id, cursor: source.cursor}
+	m.applyFilter()
+}
+
+// applyFilter rebuilds the filtered listing from its source, keeping the
+// files in their listed order and moving the cursor to the best match.
+func (m *gModel) applyFilter() {
+	source := m.unfilteredListing()
+	selected := m.filter.listing.selected()
+
+	l := listing{
+		id:         m.newListingId(),
+		name:       source.name,
+		sort:       source.sort,
+		orderBy:    source.orderBy,
+		trashed:    source.trashed,
+		showPaths:  source.showPaths,
+		subtree:    source.subtree,
+		locations:  source.locations,
+		search:     source.search,
+		highlights: map[string][]int{},
+		cursor:     -1,
+	}
+
+	best := 0
+	for _, f := range source.files {
+		positions, score, ok := fuzzyMatch(m.filter.query, f.Name)
+		if !ok {
+			continue
+		}
+		l.files = append(l.files, f)
+		l.highlights[f.Id] = positions
+		if l.cursor < 0 || score > best {
+			best = score
+			l.cursor = len(l.files) - 1
+		}
+	}
+	if m.filter.query == "" {
+		l.cursor = min(m.filter.cursor, len(l.files)-1)
+		l.selectFile(selected)
+	}
+
+	m.filter.sourceId = source.id
+	m.filter.listing = l
+}
+
+// AcceptFilter closes the filter and opens or downloads the highlighted
+// match, leaving the cursor of the listing on it.
+func (m *gModel) AcceptFilter() tea.Cmd {
+	f := m.filter.listing.selected()
+	if f == nil {
+		return nil
+	}
+	m.filter = nil
+
+	source := m.unfilteredListing()
+	source.selectFile(f)
+	if isFolder(f) {
+		return m.OpenFolder(f.Id)
+	}
+	return m.DownloadFile(f)
+}
+
+// filterKey handles keys while the filter prompt is open.
+func (m *gModel) filterKey(msg tea.KeyMsg) tea.Cmd {
+	l := &m.filter.listing
+
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.filter = nil
+	case "enter":
+		return m.AcceptFilter()
+	case "up", "ctrl+p":
+		l.moveCursor(-1)
+	case "down", "ctrl+n":
+		l.moveCursor(1)
+	case "backspace":
+		if q := []rune(m.filter.query); len(q) > 0 {
+			m.filter.query = string(q[:len(q)-1])
+			m.applyFilter()
+		}
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			m.filter.query += string(msg.Runes)
+			m.applyFilter()
+		}
+	}
+	return nil
+}
+
+// fuzzyMatch reports whether the runes of pattern appear in name in order,
+// ignoring case. It returns the matched rune positions of the best scoring
+// alignment, favouring consecutive runes and the starts of words.
+func fuzzyMatch(pattern string, name string) ([]int, int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	n := []rune(strings.ToLower(name))
+	if len(p) == 0 {
+		return nil, 0, true
+	}
+
+	var best []int
+	bestScore := 0
+	for start := range n {
+		if n[start] != p[0] {
+			continue
+		}
+		positions, score, ok := matchFrom(p, n, start)
+		if ok && (best == nil || score > bestScore) {
+			best, bestScore = positions, score
+		}
+	}
+	return best, bestScore, best != nil
+}
+
+func matchFrom(p []rune, n []rune, start int) ([]int, int, bool) {
+	positions := make([]int, 0, len(p))
+	score := 0
+	j := start
+	for _, r := range p {
+		for j < len(n) && n[j] != r {
+			j++
+		}
+		if j == len(n) {
+			return nil, 0, false
+		}
+
+		score++
+		if len(positions) > 0 && positions[len(positions)-1] == j-1 {
+			score += 5
+		}
+		if j == 0 || !unicode.IsLetter(n[j-1]) && !unicode.IsDigit(n[j-1]) {
+			score += 3
+		}
+		positions = append(positions, j)
+		j++
+	}
+	score -= positions[len(positions)-1] - positions[0] - len(p) + 1
+	return positions, score, true
+}
+
+// highlightName renders name with the runes at positions highlighted.
+func highlightName(name string, positions []int) string {
+	if len(positions) == 0 {
+		return name
+	}
+	matched := map[int]bool{}
+	for _, i := range positions {
+		matched[i] = true
+	}
+
+	var b strings.Builder
+	for i, r := range []rune(name) {
+		if matched[i] {
+			b.WriteString(matchStyle.Render(string(r)))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
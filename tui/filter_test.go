@@ -0,0 +1,29 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestReloadUnderFilter(t *testing.T) {
+	m := newTestModel(t)
+	m.update(typeKeys(m, "enter")()) // Work
+	before := crumbNames(m.listing.path)
+
+	reload := typeKeys(m, "S")
+	typeKeys(m, "f")
+	m.update(reload())
+
+	if m.listing.sort != m.sort || !m.listing.sort.descending {
+		t.Errorf("folder sorted %v, want %v", m.listing.sort, m.sort)
+	}
+	if got := crumbNames(m.listing.path); !slices.Equal(got, before) {
+		t.Errorf("reload moved to %q", got)
+	}
+	if m.filter == nil || m.filter.sourceId != m.listing.id {
+		t.Fatal("filter closed or left on the old listing")
+	}
+	if !slices.Equal(names(m.filter.listing.files), names(m.listing.files)) {
+		t.Errorf("filter shows %q over %q", names(m.filter.listing.files), names(m.listing.files))
+	}
+}
@@ -1,129 +1,354 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 
+	"drivebrowser/client"
+	"drivebrowser/files"
+	"drivebrowser/state"
+
+	tea "github.com/charmbracelet/bubbletea"
 	"google.golang.org/api/drive/v3"
 )
 
-type NavigationState struct {
-	files              []*drive.File
-	pages              [][]*drive.File
-	pageCount          int
-	currentFolderId    string
-	nextPageToken      string
-	previousPageTokens []string
-	finalPage          bool
-	cursor             int
+type gModel struct {
+	listing         listing
+	user            *drive.User
+	ctx             context.Context
+	client          client.DriveClient
+	resolver        *files.Resolver
+	rootId          string
+	searchQuery     string
+	width           int
+	height          int
+	isSearching     bool
+	searchContent   bool
+	searchModel     *listing
+	searchEdits     int
+	searchCancel    context.CancelFunc
+	walkCancel      context.CancelFunc
+	store           *state.State
+	history         historyCursor
+	savePrompt      *savePrompt
+	locationPicker  *listing
+	locations       []listing
+	navigationStack []listing
+	isTyping        bool
+	loading         bool
+	requestSeq      int
+	listingSeq      int
+	sort            sortOrder
+	showDetails     bool
+	details         map[string]*fileDetails
+	millerMode      bool
+	children        map[string]*folderChildren
+	folderPaths     map[string]string
+	treeMode        bool
+	tree            treeState
+	filter          *filterState
+	marked          map[string]*drive.File
+	rangeStart      *drive.File
+	selectPrompt    *selectPrompt
+	click           lastClick
+	downloads       int
+	status          string
+	err             error
 }
 
-type gModel struct {
-	breadcrumb         []string
-	files              []*drive.File
-	pages              [][]*drive.File
-	cursor             int
-	user               *drive.User
-	srv                *drive.Service
-	pageCount          int
-	currentFolderId    string
-	nextPageToken      string
-	searchQuery        string
-	previousPageTokens []string
-	finalPage          bool
-	width              int
-	height             int
-	isSearching        bool
-	searchModel        *searchModel
-	navigationStack    []NavigationState
-	isTyping           bool
-}
-
-func (m *gModel) FindBreadCrumb(srv *drive.Service, folderId string) error {
-	f, err := srv.Files.Get(folderId).Fields("name").Do()
-	if err != nil {
-		return err
-	}
-
-	m.breadcrumb = append(m.breadcrumb, f.Name)
+// activeListing returns the filtered entries while filtering, the search
+// results while searching and the current folder otherwise.
+func (m *gModel) activeListing() *listing {
+	if m.filter != nil && m.filter.sourceId == m.unfilteredListing().id {
+		return &m.filter.listing
+	}
+	return m.unfilteredListing()
+}
+
+func (m *gModel) unfilteredListing() *listing {
+	if m.locationPicker != nil {
+		return m.locationPicker
+	}
+	if m.isSearching && m.searchModel != nil {
+		return m.searchModel
+	}
+	return &m.listing
+}
 
+// listingById returns the shown listing with the given id, or nil when it
+// is no longer on screen.
+func (m *gModel) listingById(id int) *listing {
+	if m.listing.id == id {
+		return &m.listing
+	}
+	if m.searchModel != nil && m.searchModel.id == id {
+		return m.searchModel
+	}
+	if m.locationPicker != nil && m.locationPicker.id == id {
+		return m.locationPicker
+	}
+	// Recursive searches and search results left for one of their folders
+	// keep loading.
+	for i, l := range m.navigationStack {
+		if l.subtree && l.id == id {
+			return &m.navigationStack[i]
+		}
+		if l.results != nil && l.results.id == id {
+			return l.results
+		}
+	}
 	return nil
+}
+
+// selectedFile returns the highlighted file, which in tree mode may be
+// nested below the active listing.
+func (m *gModel) selectedFile() *drive.File {
+	if m.treeMode && m.locationPicker == nil {
+		if r := m.selectedTreeRow(); r != nil {
+			return r.file
+		}
+		return nil
+	}
+	return m.activeListing().selected()
+}
 
+func (m *gModel) newListingId() int {
+	m.listingSeq++
+	return m.listingSeq
 }
 
+// listHeight is the number of rows available to the file list.
+func (m *gModel) listHeight() int {
+	height := m.height - chromeHeight
+	if m.height == 0 {
+		height = 10
+	}
+	if m.showDetails && !m.detailsBeside() {
+		height -= detailsHeight
+	}
+	return max(1, height)
+}
+
+// SaveCurrentState pushes the current folder onto the navigation history,
+// leaving any search. The search results are kept with the folder so going
+// back returns to them.
 func (m *gModel) SaveCurrentState() {
-	state := NavigationState{
-		files:              m.files,
-		pages:              m.pages,
-		pageCount:          m.pageCount,
-		currentFolderId:    m.currentFolderId,
-		nextPageToken:      m.nextPageToken,
-		previousPageTokens: m.previousPageTokens,
-		finalPage:          m.finalPage,
-		cursor:             m.cursor,
+	l := m.listing
+	if m.isSearching && m.searchModel != nil {
+		l.results = m.searchModel
 	}
+	m.navigationStack = append(m.navigationStack, l)
 
-	m.navigationStack = append(m.navigationStack, state)
+	m.isSearching = false
+	m.searchModel = nil
+	m.searchQuery = ""
 }
 
-func (m *gModel) OpenFolder(id string) error {
-	r, err := m.srv.Files.List().PageSize(10).
-		Q(fmt.Sprintf("'%s' in parents", id)).
-		Fields("nextPageToken, files(id, name, mimeType)").Do()
-	if err != nil {
-		return err
+func (m *gModel) OpenFolder(id string) tea.Cmd {
+	seq := m.startRequest()
+	trashed := m.activeListing().trashed
+	sort := m.sort
+	known := m.listing.path
+	ctx, c := m.ctx, m.client
+
+	return func() tea.Msg {
+		f, err := c.GetFile(ctx, id, crumbFields)
+		if err != nil {
+			return errMsg{seq: seq, err: err}
+		}
+		l := folderListing(f.Id, f.Name, f.DriveId)
+		l.path = folderPath(ctx, c, f, known)
+		if trashed {
+			l = l.inTrash()
+		}
+		l.sort = sort
+		res, err := c.List(ctx, l.options())
+		if err != nil {
+			return errMsg{seq: seq, err: err}
+		}
+		l.addPage(res)
+		return folderLoadedMsg{seq: seq, listing: l}
+	}
+}
+
+// OpenSelected opens the highlighted location or folder, or downloads the
+// highlighted file together with any other marked files.
+func (m *gModel) OpenSelected() tea.Cmd {
+	current := m.activeListing()
+	f := current.selected()
+	if f == nil {
+		return nil
 	}
+	if m.locationPicker != nil {
+		return m.OpenLocation(m.locations[current.cursor])
+	}
+	if isFolder(f) {
+		return m.OpenFolder(f.Id)
+	}
+	return m.DownloadSelected()
+}
 
+func (m *gModel) ShowFolder(l listing) {
 	m.SaveCurrentState()
 
-	m.FindBreadCrumb(m.srv, m.files[m.cursor].Id)
+	l.id = m.newListingId()
+	m.listing = l
+}
 
-	m.files = r.Files
-	m.currentFolderId = id
-	m.nextPageToken = r.NextPageToken
-	m.cursor = 0
-	m.pageCount = 1
-	m.previousPageTokens = []string{}
-	m.pages = [][]*drive.File{}
+// FetchMore requests the next page of the active listing once the cursor
+// gets within a screen of the last loaded file.
+func (m *gModel) FetchMore() tea.Cmd {
+	l := m.activeListing()
+	if l.fetching || l.stalled || !l.hasMore() {
+		return nil
+	}
+	if !l.nearEnd(m.listHeight()) && !l.localSort() {
+		return nil
+	}
+	l.fetching = true
 
-	return nil
+	ctx, c := m.ctx, m.client
+	id, opts := l.id, l.options()
+
+	return func() tea.Msg {
+		res, err := c.List(ctx, opts)
+		return pageLoadedMsg{listingId: id, token: opts.PageToken, res: res, err: err}
+	}
 }
 
-func (m *gModel) LoadNextPage() error {
-	call := m.srv.Files.List().PageSize(10).
-		OrderBy("name").
-		Fields("nextPageToken, files(id, name, mimeType)")
+func (m *gModel) ShowPage(msg pageLoadedMsg) {
+	l := m.listingById(msg.listingId)
+	if l == nil || l.nextPageToken != msg.token {
+		return
+	}
+	if msg.err != nil {
+		l.fetching = false
+		l.stalled = true
+		m.err = msg.err
+		return
+	}
+	l.addPage(msg.res)
+	if m.filter != nil && m.filter.sourceId == l.id {
+		m.applyFilter()
+	}
+}
 
-	if m.nextPageToken != "" {
-		call = call.PageToken(m.nextPageToken)
+// SetSort changes the session sort order and reloads the active listing in
+// that order, keeping the cursor on the same file where possible.
+func (m *gModel) SetSort(s sortOrder) tea.Cmd {
+	m.sort = s
+	if m.locationPicker != nil {
+		return nil
 	}
 
-	res, err := call.Do()
-	if err != nil {
-		return err
+	// A filter only shows a copy of the files, so its source is reloaded.
+	current := m.unfilteredListing()
+	selected := m.activeListing().selected()
+	clear(m.children)
+	if current.subtree {
+		// Recursive searches are not paged, so they are sorted in place.
+		current.sort = s
+		current.sort.sortFiles(current.files)
+		current.selectFile(selected)
+		if m.filter != nil && m.filter.sourceId == current.id {
+			m.applyFilter()
+		}
+		return nil
 	}
 
-	m.previousPageTokens = append(m.previousPageTokens, m.nextPageToken)
-	m.files = res.Files
-	m.nextPageToken = res.NextPageToken
-	m.cursor = 0
-	m.pages = append(m.pages, res.Files)
-	m.pageCount++
-	return nil
+	id := current.id
+	l := current.reset()
+	l.sort = s
+
+	seq := m.startRequest()
+	return listCmd(m.ctx, m.client, seq, l.options(), func(res *drive.FileList) tea.Msg {
+		l.addPage(res)
+		l.selectFile(selected)
+		return listingReloadedMsg{seq: seq, listingId: id, listing: l}
+	})
 }
 
-func (m *gModel) LoadCachedPage(currPage int) error {
-	index := currPage - 1
+// ShowReloaded replaces the listing that was reloaded, if it is still
+// shown, keeping any filter over it open.
+func (m *gModel) ShowReloaded(msg listingReloadedMsg) {
+	target := m.listingById(msg.listingId)
+	if target == nil {
+		return
+	}
+	filtered := m.filter != nil && m.filter.sourceId == target.id
+
+	l := msg.listing
+	l.id = m.newListingId()
+	*target = l
+	if filtered {
+		m.applyFilter()
+	}
+}
 
-	if index < 0 || index >= len(m.pages) {
+// JumpToAncestor goes to the folder at the given depth of the breadcrumb,
+// leaving any search. It returns to the folder in the navigation history
+// when it was opened on the way down, and opens it otherwise.
+func (m *gModel) JumpToAncestor(depth int) tea.Cmd {
+	path := m.listing.path
+	if depth < 0 || depth >= len(path) {
+		m.err = fmt.Errorf("No folder at depth %d", depth+1)
 		return nil
 	}
-	m.files = m.pages[index]
-	m.pageCount--
-	m.nextPageToken = m.previousPageTokens[index]
-	m.cursor = 0
 
-	return nil
+	m.cancelPending()
+	m.cancelSearch()
+	m.isSearching = false
+	m.searchModel = nil
+	m.searchQuery = ""
+	if depth == len(path)-1 {
+		return nil
+	}
 
+	target := path[depth]
+	for i := len(m.navigationStack) - 1; i >= 0; i-- {
+		l := m.navigationStack[i]
+		if l.folderId == target.id && len(l.path) == depth+1 {
+			m.listing = l
+			m.listing.fetching = m.listing.subtree && m.listing.fetching
+			// Only going back returns to the search the folder was left for.
+			m.listing.results = nil
+			m.navigationStack = m.navigationStack[:i]
+			return m.refreshListing()
+		}
+	}
+
+	if target.id == "" {
+		return nil
+	}
+	return m.OpenFolder(target.id)
+}
+
+// OpenParent goes to the parent of the current folder, which may not have
+// been visited yet.
+func (m *gModel) OpenParent() tea.Cmd {
+	if len(m.listing.path) < 2 {
+		m.err = fmt.Errorf("No parent folder")
+		return nil
+	}
+	return m.JumpToAncestor(len(m.listing.path) - 2)
+}
+
+// GoBack returns to the previous folder of the navigation history.
+func (m *gModel) GoBack() tea.Cmd {
+	m.cancelPending()
+	if m.err = m.RestorePreviousState(); m.err != nil {
+		return nil
+	}
+	return m.refreshListing()
+}
+
+// refreshListing loads the folder returned to when it was never loaded, as
+// for the ancestors of the starting folder, or was sorted differently.
+func (m *gModel) refreshListing() tea.Cmd {
+	if !m.listing.loaded || m.listing.sort != m.sort {
+		return m.SetSort(m.sort)
+	}
+	return nil
 }
 
 func (m *gModel) RestorePreviousState() error {
@@ -133,22 +358,18 @@ func (m *gModel) RestorePreviousState() error {
 	}
 
 	lastIndex := len(m.navigationStack) - 1
-	state := m.navigationStack[lastIndex]
-
-	m.files = state.files
-	m.pages = state.pages
-	m.pageCount = state.pageCount
-	m.currentFolderId = state.currentFolderId
-	m.nextPageToken = state.nextPageToken
-	m.previousPageTokens = state.previousPageTokens
-	m.finalPage = state.finalPage
-	m.cursor = state.cursor
-
+	m.listing = m.navigationStack[lastIndex]
+	// Pages requested for a folder are dropped once it is left, but the
+	// walk of a recursive search goes on.
+	m.listing.fetching = m.listing.subtree && m.listing.fetching
 	m.navigationStack = m.navigationStack[:lastIndex]
 
-	// Remove last breadcrumb
-	if len(m.breadcrumb) > 1 {
-		m.breadcrumb = m.breadcrumb[:len(m.breadcrumb)-2]
+	if results := m.listing.results; results != nil {
+		m.listing.results = nil
+		m.searchModel = results
+		m.isSearching = true
+		m.searchQuery = results.search.Query
+		m.searchContent = results.search.Content
 	}
 
 	return nil
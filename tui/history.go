@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	"drivebrowser/state"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/api/drive/v3"
+)
+
+// savePrompt is the prompt for the name of a search being saved.
+type savePrompt struct {
+	name string
+}
+
+// historyCursor is the position of the search prompt in the history. It
+// is past the end while typing a new query, which draft keeps while older
+// queries are recalled.
+type historyCursor struct {
+	index int
+	draft state.Search
+}
+
+// typedSearch is the query at the search prompt and where it was typed.
+func (m *gModel) typedSearch() state.Search {
+	return state.Search{
+		Query:    m.searchQuery,
+		Content:  m.searchContent,
+		FolderId: m.listing.folderId,
+		DriveId:  m.listing.driveId,
+	}
+}
+
+// RememberSearch adds the typed search to the history file.
+func (m *gModel) RememberSearch() {
+	m.store.AddHistory(m.typedSearch())
+	m.history = historyCursor{index: len(m.store.History)}
+	if err := m.store.Save(); err != nil {
+		m.err = fmt.Errorf("Unable to save search history: %w", err)
+	}
+}
+
+// RecallSearch replaces the typed search with an older (delta < 0) or
+// newer one from the history. It reports whether the query changed.
+func (m *gModel) RecallSearch(delta int) bool {
+	history := m.store.History
+	index := min(max(m.history.index+delta, 0), len(history))
+	if index == m.history.index {
+		return false
+	}
+	if m.history.index == len(history) {
+		m.history.draft = m.typedSearch()
+	}
+	m.history.index = index
+
+	recalled := m.history.draft
+	if index < len(history) {
+		recalled = history[index]
+	}
+	m.searchQuery = recalled.Query
+	m.searchContent = recalled.Content
+	return true
+}
+
+// StartSaveSearch opens the prompt for saving the shown search results.
+func (m *gModel) StartSaveSearch() {
+	if !m.isSearching || m.searchModel == nil || m.searchModel.search == nil {
+		m.err = fmt.Errorf("No search to save")
+		return
+	}
+	m.savePrompt = &savePrompt{name: m.searchModel.search.Query}
+}
+
+// saveKey handles keys while the save prompt is open.
+func (m *gModel) saveKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.savePrompt = nil
+	case "enter":
+		name := m.savePrompt.name
+		m.savePrompt = nil
+		if name == "" || m.searchModel == nil {
+			return nil
+		}
+		s := *m.searchModel.search
+		s.Name = name
+		m.store.SaveSearch(s)
+		if err := m.store.Save(); err != nil {
+			m.err = fmt.Errorf("Unable to save search: %w", err)
+			return nil
+		}
+		m.status = fmt.Sprintf("Saved search %q, open it with D", name)
+	case "backspace":
+		if n := []rune(m.savePrompt.name); len(n) > 0 {
+			m.savePrompt.name = string(n[:len(n)-1])
+		}
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			m.savePrompt.name += string(msg.Runes)
+		}
+	}
+	return nil
+}
+
+// savedViews are the saved searches, shown as virtual folders next to the
+// built in views. Searches that no longer parse are left out.
+func savedViews(saved []state.Search) []listing {
+	var views []listing
+	for _, s := range saved {
+		parsed, err := parseSearch(s.Query, s.FolderId, s.Content)
+		if err != nil {
+			continue
+		}
+		l := searchListing(s.Query, parsed, s.DriveId)
+		l.name = s.Name
+		l.search = &s
+		l.subtree = parsed.subtree
+		l.showPaths = !parsed.subtree
+		views = append(views, l)
+	}
+	return views
+}
+
+// OpenSavedSubtree shows a saved search of a folder and everything below
+// it, streaming its results into the new top level.
+func (m *gModel) OpenSavedSubtree(l listing) tea.Cmd {
+	parsed, err := parseSearch(l.search.Query, l.search.FolderId, l.search.Content)
+	if err != nil {
+		m.err = err
+		return nil
+	}
+	m.cancelPending()
+
+	l.sort = m.sort
+	l.locations = map[string]string{}
+	l.files = []*drive.File{}
+	l.fetching = true
+	l.loaded = true
+	m.ShowLocation(l)
+
+	// The walk lasts until another location replaces this one.
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.walkCancel = cancel
+	walk := newSubtreeWalk(ctx, l.search.FolderId, parsed, l.search.DriveId)
+	return m.stepSubtree(m.listing.id, walk)
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"drivebrowser/state"
+)
+
+func TestRecallSearch(t *testing.T) {
+	m := newTestModel(t)
+	m.store.AddHistory(state.Search{Query: "report"})
+	m.store.AddHistory(state.Search{Query: "plan", Content: true})
+
+	typeKeys(m, "/", "W")
+	var got []string
+	for _, k := range []string{"up", "up", "up", "down", "down", "down"} {
+		if k == "up" {
+			m.RecallSearch(-1)
+		} else {
+			m.RecallSearch(1)
+		}
+		got = append(got, m.searchQuery)
+	}
+	want := []string{"plan", "report", "report", "plan", "W", "W"}
+	if !slices.Equal(got, want) {
+		t.Errorf("recalled %q, want %q", got, want)
+	}
+	if m.searchContent {
+		t.Error("content mode of the recalled search kept after returning to the draft")
+	}
+}
+
+func TestSavedSubtreeKeepsWalkingWhileAway(t *testing.T) {
+	m := newTestModel(t)
+	work := m.listing.files[0]
+	m.store.SaveSearch(state.Search{Name: "E below Work", Query: "e in:below", FolderId: work.Id})
+
+	run(m, typeKeys(m, "D"))
+	m.locationPicker.cursor = len(m.locations) - 1
+	walk := typeKeys(m, "enter")
+	if m.listing.name != "E below Work" {
+		t.Fatalf("opened %s, want the saved search", m.listing.name)
+	}
+
+	// Walk until the Reports folder is among the results, then open it.
+	reports := -1
+	for walk != nil && reports < 0 {
+		walk = m.update(walk())
+		reports = slices.Index(names(m.listing.files), "Reports")
+	}
+	if reports < 0 {
+		t.Fatalf("Reports not found, got %q", names(m.listing.files))
+	}
+	m.listing.cursor = reports
+	run(m, typeKeys(m, "enter"))
+	if m.listing.name != "Reports" {
+		t.Fatalf("opened %s, want Reports", m.listing.name)
+	}
+
+	for walk != nil {
+		walk = m.update(walk())
+	}
+	run(m, m.GoBack())
+
+	want := []string{"report.txt", "Reports", "Wx notes.txt"}
+	if got := names(m.listing.files); !slices.Equal(got, want) {
+		t.Errorf("saved search shows %q after going back, want %q", got, want)
+	}
+	if m.listing.fetching {
+		t.Error("saved search still fetching after its walk ended")
+	}
+}
@@ -0,0 +1,211 @@
+package tui
+
+import (
+	"drivebrowser/client"
+	"drivebrowser/state"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// pageSize is the number of files requested per Drive page. Pages are
+// fetched lazily as the cursor approaches the end of what has been loaded.
+const pageSize = 100
+
+// relevanceOrder is the order of full-text searches, which Drive returns by
+// relevance and cannot sort.
+const relevanceOrder = "relevance"
+
+// listing is a lazily loaded list of files (a folder or search results)
+// together with the query that produced it, so that further pages always
+// continue the listing that is on screen. Listings are ordered by sort
+// unless they have a fixed orderBy of their own.
+type listing struct {
+	id            int
+	folderId      string
+	driveId       string
+	name          string
+	query         string
+	orderBy       string
+	sort          sortOrder
+	corpora       string
+	trashed       bool
+	files         []*drive.File
+	nextPageToken string
+	fetching      bool
+	stalled       bool
+	cursor        int
+	offset        int
+	highlights    map[string][]int
+	path          []crumb
+	loaded        bool
+	showPaths     bool
+	subtree       bool
+	locations     map[string]string
+	search        *state.Search
+	results       *listing
+}
+
+// folderListing lists the children of a folder. driveId is set for folders
+// inside a shared drive. Its breadcrumb is just the folder until the rest
+// of the path is known.
+func folderListing(id string, name string, driveId string) listing {
+	return listing{
+		folderId: id,
+		driveId:  driveId,
+		name:     name,
+		query:    client.And(client.InParents(id), client.Is("trashed", false)),
+		path:     []crumb{{id: id, name: name, driveId: driveId}},
+	}
+}
+
+// inTrash lists the trashed children of the folder instead, for browsing
+// folders in the trash.
+func (l listing) inTrash() listing {
+	l.query = client.And(client.InParents(l.folderId), client.Is("trashed", true))
+	l.trashed = true
+	return l
+}
+
+// searchListing lists the results of the search typed as text within the
+// shared drive driveId, or across every drive the user can access when
+// driveId is empty.
+func searchListing(text string, s parsedSearch, driveId string) listing {
+	l := listing{
+		driveId:   driveId,
+		name:      "Search: " + text,
+		query:     s.query,
+		trashed:   s.trashed,
+		showPaths: true,
+	}
+	if s.fullText {
+		l.orderBy = relevanceOrder
+	}
+	if driveId == "" {
+		l.corpora = "allDrives"
+	}
+	return l
+}
+
+// options returns the request for the next page that has not been fetched yet.
+func (l *listing) options() client.ListOptions {
+	orderBy := l.orderBy
+	switch orderBy {
+	case "":
+		orderBy = l.sort.orderBy()
+	case relevanceOrder:
+		orderBy = ""
+	}
+
+	return client.ListOptions{
+		Query:     l.query,
+		OrderBy:   orderBy,
+		PageSize:  pageSize,
+		PageToken: l.nextPageToken,
+		DriveId:   l.driveId,
+		Corpora:   l.corpora,
+	}
+}
+
+func (l *listing) selected() *drive.File {
+	if l.cursor < 0 || l.cursor >= len(l.files) {
+		return nil
+	}
+	return l.files[l.cursor]
+}
+
+func (l *listing) addPage(res *drive.FileList) {
+	selected := l.selected()
+
+	l.files = append(l.files, res.Files...)
+	l.nextPageToken = res.NextPageToken
+	l.fetching = false
+	l.loaded = true
+
+	if l.localSort() {
+		l.sort.sortFiles(l.files)
+		l.selectFile(selected)
+	}
+}
+
+// localSort reports whether the listing has to be fully loaded and sorted
+// locally because Drive cannot order by its sort field.
+func (l *listing) localSort() bool {
+	return l.orderBy == "" && l.sort.local()
+}
+
+// selectFile moves the cursor to f if it is loaded.
+func (l *listing) selectFile(f *drive.File) {
+	if f == nil {
+		return
+	}
+	for i, file := range l.files {
+		if file.Id == f.Id {
+			l.cursor = i
+			return
+		}
+	}
+}
+
+// reset returns an unloaded copy of the listing, to fetch it again from
+// the start.
+func (l listing) reset() listing {
+	l.files = nil
+	l.nextPageToken = ""
+	l.fetching = false
+	l.stalled = false
+	l.cursor = 0
+	l.offset = 0
+	l.loaded = false
+	return l
+}
+
+// orderString describes the order of the listing for the header.
+func (l *listing) orderString() string {
+	if l.orderBy != "" {
+		return l.orderBy
+	}
+	return l.sort.String()
+}
+
+func (l *listing) hasMore() bool {
+	return l.nextPageToken != ""
+}
+
+// moveCursor moves the cursor by delta rows. It only wraps around once
+// every page has been loaded, otherwise it stops at the last loaded file
+// while the next page is fetched.
+func (l *listing) moveCursor(delta int) {
+	if len(l.files) == 0 {
+		return
+	}
+
+	next := l.cursor + delta
+	switch {
+	case next < 0 && l.cursor == 0 && delta == -1 && !l.hasMore():
+		next = len(l.files) - 1
+	case next < 0:
+		next = 0
+	case next > len(l.files)-1 && l.cursor == len(l.files)-1 && delta == 1 && !l.hasMore():
+		next = 0
+	case next > len(l.files)-1:
+		next = len(l.files) - 1
+	}
+	l.cursor = next
+}
+
+// scroll keeps the cursor inside a viewport of the given height.
+func (l *listing) scroll(height int) {
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	}
+	if l.cursor >= l.offset+height {
+		l.offset = l.cursor - height + 1
+	}
+	l.offset = max(0, min(l.offset, len(l.files)-height))
+}
+
+// nearEnd reports whether the cursor is within one screen of the last
+// loaded file.
+func (l *listing) nearEnd(height int) bool {
+	return l.cursor >= len(l.files)-height
+}
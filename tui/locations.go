@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"drivebrowser/client"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/api/drive/v3"
+)
+
+type locationsLoadedMsg struct {
+	seq       int
+	locations []listing
+}
+
+type locationOpenedMsg struct {
+	seq     int
+	listing listing
+}
+
+// virtualViews are the top level locations that are not a folder, each
+// backed by its own query like the views of the Drive web UI.
+func virtualViews() []listing {
+	return []listing{
+		{
+			name:    "Shared with me",
+			query:   client.And(client.Is("sharedWithMe", true), client.Is("trashed", false)),
+			orderBy: "sharedWithMeTime desc",
+		},
+		{
+			name:    "Starred",
+			query:   client.And(client.Is("starred", true), client.Is("trashed", false)),
+			corpora: "allDrives",
+		},
+		{
+			name:    "Recent",
+			query:   client.And(client.Compare("mimeType", "!=", "application/vnd.google-apps.folder"), client.Is("trashed", false)),
+			orderBy: "recency desc",
+			corpora: "allDrives",
+		},
+		{
+			name:    "Trash",
+			query:   client.Is("trashed", true),
+			trashed: true,
+		},
+	}
+}
+
+// OpenLocationPicker lists My Drive, the virtual views, the saved searches
+// and every shared drive the user can access so the browser can switch
+// between them.
+func (m *gModel) OpenLocationPicker() tea.Cmd {
+	seq := m.startRequest()
+	ctx, c := m.ctx, m.client
+	rootId := m.rootId
+	saved := savedViews(m.store.Saved)
+
+	return func() tea.Msg {
+		locations := []listing{folderListing(rootId, "My Drive", "")}
+		locations = append(locations, virtualViews()...)
+		locations = append(locations, saved...)
+
+		pageToken := ""
+		for {
+			res, err := c.ListDrives(ctx, pageToken)
+			if err != nil {
+				return errMsg{seq: seq, err: err}
+			}
+			for _, d := range res.Drives {
+				locations = append(locations, folderListing(d.Id, d.Name, d.Id))
+			}
+			if res.NextPageToken == "" {
+				break
+			}
+			pageToken = res.NextPageToken
+		}
+
+		return locationsLoadedMsg{seq: seq, locations: locations}
+	}
+}
+
+func (m *gModel) ShowLocationPicker(locations []listing) {
+	picker := listing{id: m.newListingId(), name: "Go to"}
+	for _, l := range locations {
+		picker.files = append(picker.files, &drive.File{
+			Name:     l.name,
+			MimeType: "application/vnd.google-apps.folder",
+		})
+	}
+
+	m.locations = locations
+	m.locationPicker = &picker
+}
+
+// OpenLocation loads the first page of a location from the picker.
+func (m *gModel) OpenLocation(l listing) tea.Cmd {
+	if l.subtree {
+		return m.OpenSavedSubtree(l)
+	}
+	seq := m.startRequest()
+	l.sort = m.sort
+
+	return listCmd(m.ctx, m.client, seq, l.options(), func(res *drive.FileList) tea.Msg {
+		l.addPage(res)
+		return locationOpenedMsg{seq: seq, listing: l}
+	})
+}
+
+// ShowLocation makes l the new top level, discarding the navigation history
+// of the previous location.
+func (m *gModel) ShowLocation(l listing) {
+	if m.walkCancel != nil {
+		m.walkCancel()
+		m.walkCancel = nil
+	}
+	m.cancelSearch()
+
+	l.id = m.newListingId()
+	m.navigationStack = []listing{}
+	if len(l.path) == 0 {
+		l.path = []crumb{{name: l.name}}
+	}
+	m.listing = l
+	m.locationPicker = nil
+	m.isSearching = false
+	m.searchModel = nil
+	m.searchQuery = ""
+}
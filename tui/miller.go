@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	lipgloss "github.com/charmbracelet/lipgloss"
+	"google.golang.org/api/drive/v3"
+)
+
+// FetchPreview fills the parent and preview columns of the Miller layout
+// for the current folder and the highlighted entry.
+func (m *gModel) FetchPreview() tea.Cmd {
+	if !m.millerMode || m.locationPicker != nil {
+		return nil
+	}
+	return tea.Batch(m.FetchParent(), m.FetchChildren(m.selectedFile()))
+}
+
+// parentFolder is the folder above the current one in its breadcrumb, or
+// nil at the top of a location.
+func (m *gModel) parentFolder() *drive.File {
+	path := m.listing.path
+	if len(path) < 2 || path[len(path)-2].id == "" {
+		return nil
+	}
+	parent := path[len(path)-2]
+	return &drive.File{
+		Id:       parent.id,
+		Name:     parent.name,
+		DriveId:  parent.driveId,
+		MimeType: "application/vnd.google-apps.folder",
+	}
+}
+
+// FetchParent loads the children of the parent folder into the children
+// cache, page by page until the current folder is among them.
+func (m *gModel) FetchParent() tea.Cmd {
+	parent := m.parentFolder()
+	if parent == nil || m.isSearching {
+		return nil
+	}
+	entry, ok := m.children[parent.Id]
+	if !ok {
+		return m.FetchChildren(parent)
+	}
+	if entry.loaded() && entry.cursorOn(m.listing.folderId) < 0 {
+		return m.FetchMoreChildren(parent.Id)
+	}
+	return nil
+}
+
+// parentListing is the listing shown left of the active one in the Miller
+// layout: the folder searched from while searching, and otherwise the
+// parent of the current folder with the current folder highlighted.
+func (m *gModel) parentListing() *listing {
+	if m.locationPicker != nil {
+		return nil
+	}
+	if m.isSearching && m.searchModel != nil {
+		return &m.listing
+	}
+	parent := m.parentFolder()
+	if parent == nil {
+		return nil
+	}
+	entry, ok := m.children[parent.Id]
+	if !ok || !entry.loaded() || entry.err != nil {
+		return nil
+	}
+	l := entry.listing
+	l.cursor = entry.cursorOn(m.listing.folderId)
+	return &l
+}
+
+// millerWidths splits the width of the screen between the parent, current
+// and preview columns, leaving room for the separators.
+func (m *gModel) millerWidths() (int, int, int) {
+	width := m.width
+	if width == 0 {
+		width = 80
+	}
+	parentWidth := width / 4
+	currentWidth := width * 3 / 8
+	return parentWidth, currentWidth, width - parentWidth - currentWidth - 4
+}
+
+// renderMiller renders the parent, current and preview columns.
+func (m gModel) renderMiller() string {
+	rows := m.listHeight()
+	parentWidth, currentWidth, previewWidth := m.millerWidths()
+
+	parent := ""
+	if p := m.parentListing(); p != nil {
+		shown := *p
+		shown.scroll(rows)
+		parent = renderRows(&shown, rows, nil, nil)
+	}
+
+	current := m.activeListing()
+	preview := m.renderPreview(current.selected(), rows)
+
+	column := func(s string, w int) string {
+		s = strings.TrimPrefix(s, "\n")
+		s = lipgloss.NewStyle().MaxWidth(w).Render(s)
+		return lipgloss.NewStyle().Width(w).Height(rows).Render(s)
+	}
+	separator := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#5F5F5F")).
+		Render(strings.TrimSuffix(strings.Repeat("│\n", rows), "\n"))
+
+	return "\n" + lipgloss.JoinHorizontal(lipgloss.Top,
+		column(parent, parentWidth),
+		separator+" ",
+		column(renderRows(current, rows, m.markedSet(), nil), currentWidth),
+		separator+" ",
+		column(preview, previewWidth),
+	)
+}
+
+func (m gModel) renderPreview(f *drive.File, rows int) string {
+	if f == nil || m.locationPicker != nil {
+		return ""
+	}
+
+	if !isFolder(f) {
+		d := m.details[f.Id]
+		switch {
+		case d == nil || (d.file == nil && d.err == nil):
+			return "Loading details..."
+		case d.err != nil:
+			return "Error: " + d.err.Error()
+		}
+		return formatDetails(d.file, d.paths)
+	}
+
+	children := m.children[f.Id]
+	switch {
+	case children == nil || !children.loaded():
+		return "Loading..."
+	case children.err != nil:
+		return "Error: " + children.err.Error()
+	case len(children.files) == 0:
+		return "(empty folder)"
+	}
+
+	return renderRows(&listing{files: children.files, cursor: -1}, rows, nil, nil)
+}
@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	lipgloss "github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// doubleClickTime is the longest gap between two clicks on the same
+	// row that still counts as a double click.
+	doubleClickTime = 400 * time.Millisecond
+
+	// wheelRows is the number of rows one step of the mouse wheel scrolls.
+	wheelRows = 3
+)
+
+// lastClick remembers the previous click for detecting double clicks.
+type lastClick struct {
+	at  time.Time
+	row int
+}
+
+func (m *gModel) handleMouse(msg tea.MouseMsg) tea.Cmd {
+	if m.isTyping || m.filter != nil || m.selectPrompt != nil || m.savePrompt != nil {
+		return nil
+	}
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		m.moveBy(-wheelRows)
+		return nil
+	case tea.MouseButtonWheelDown:
+		m.moveBy(wheelRows)
+		return nil
+	case tea.MouseButtonLeft:
+		if msg.Action != tea.MouseActionPress {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	header := m.headerBox()
+	if msg.Y == 2 {
+		return m.clickBreadcrumb(header, msg.X)
+	}
+
+	row := msg.Y - lipgloss.Height(header) - 1
+	if row < 0 || row >= m.listHeight() {
+		return nil
+	}
+
+	if m.millerMode && m.locationPicker == nil {
+		parentWidth, currentWidth, _ := m.millerWidths()
+		switch {
+		case msg.X < parentWidth:
+			if m.parentListing() == nil || m.isSearching {
+				return nil
+			}
+			return m.OpenParent()
+		case msg.X >= parentWidth+2+currentWidth:
+			return nil
+		}
+	}
+
+	return m.clickRow(row)
+}
+
+// clickRow moves the cursor to the clicked row of the list, and opens it
+// when it was clicked twice in a row.
+func (m *gModel) clickRow(row int) tea.Cmd {
+	var index int
+	if m.treeMode && m.locationPicker == nil {
+		rows := m.syncTree()
+		index = m.tree.offset + row
+		if index >= len(rows) {
+			return nil
+		}
+		m.moveTree(rows, index)
+	} else {
+		current := m.activeListing()
+		index = current.offset + row
+		if index >= len(current.files) {
+			return nil
+		}
+		current.cursor = index
+	}
+
+	now := time.Now()
+	double := m.click.row == index && now.Sub(m.click.at) < doubleClickTime
+	m.click = lastClick{at: now, row: index}
+	if !double {
+		return nil
+	}
+
+	m.click = lastClick{}
+	if m.treeMode && m.locationPicker == nil {
+		cmd, _ := m.treeKey("enter")
+		return cmd
+	}
+	return m.OpenSelected()
+}
+
+// clickBreadcrumb jumps to the ancestor whose breadcrumb segment is at
+// column x of the header.
+func (m *gModel) clickBreadcrumb(header string, x int) tea.Cmd {
+	if m.locationPicker != nil {
+		return nil
+	}
+
+	// The header is centred; its text starts after the border and padding.
+	start := max(0, m.width-lipgloss.Width(header))/2 + 2
+	for depth, name := range crumbNames(m.listing.path) {
+		end := start + lipgloss.Width(name)
+		if x >= start && x < end {
+			return m.JumpToAncestor(depth)
+		}
+		start = end + lipgloss.Width(breadcrumbSeparator)
+	}
+	return nil
+}
+
+// moveBy moves the cursor of the list or the tree by delta rows.
+func (m *gModel) moveBy(delta int) {
+	if m.treeMode && m.locationPicker == nil {
+		key := "down"
+		if delta < 0 {
+			key, delta = "up", -delta
+		}
+		for range delta {
+			m.treeKey(key)
+		}
+		return
+	}
+	current := m.activeListing()
+	current.stalled = false
+	current.moveCursor(delta)
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
+	"drivebrowser/client"
 	"drivebrowser/files"
+	"drivebrowser/state"
 	"drivebrowser/utils"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -13,33 +16,81 @@ import (
 	"google.golang.org/api/drive/v3"
 )
 
-func InitialModel(ctx context.Context, srv *drive.Service, folderId string) gModel {
-	file_list, nextToken := files.ListFiles(srv)
+// InitialModel starts in the folder with the given id, or in the folder of
+// the file with that id with the file selected. The folders above it are
+// put on the navigation stack, so going back walks up its path.
+func InitialModel(ctx context.Context, c client.DriveClient, id string) gModel {
+	f, err := c.GetFile(ctx, id, crumbFields+", mimeType")
+	if err != nil {
+		log.Fatalf("Unable to retrieve %s: %v", id, err)
+	}
+
+	var selected *drive.File
+	if !isFolder(f) {
+		if len(f.Parents) == 0 {
+			log.Fatalf("Unable to find the folder of %s", f.Name)
+		}
+		selected = f
+		f, err = c.GetFile(ctx, f.Parents[0], crumbFields)
+		if err != nil {
+			log.Fatalf("Unable to retrieve the folder of %s: %v", selected.Name, err)
+		}
+	}
+
+	root := folderListing(f.Id, f.Name, f.DriveId)
+	root.path = folderPath(ctx, c, f, nil)
+	for {
+		res, err := c.List(ctx, root.options())
+		if err != nil {
+			log.Fatalf("Unable to retrieve files: %v", err)
+		}
+		root.addPage(res)
+		root.selectFile(selected)
+
+		if selected == nil || !root.hasMore() {
+			break
+		}
+		// A page may come back empty with more to follow.
+		if f := root.selected(); f != nil && f.Id == selected.Id {
+			break
+		}
+	}
 
-	user_name, err := srv.About.Get().Fields("user(displayName, emailAddress)").Context(ctx).Do()
+	user, err := c.About(ctx)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
+	// Files name My Drive by its real id rather than the "root" alias.
+	myDrive, err := c.GetFile(ctx, "root", "id")
+	if err != nil {
+		log.Fatalf("Unable to retrieve My Drive: %v", err)
+	}
+	store, err := state.Load(user.EmailAddress)
+	if err != nil {
+		err = fmt.Errorf("Unable to load search history: %w", err)
+	}
 
 	return gModel{
-		breadcrumb:         []string{"My Drive"},
-		files:              file_list,
-		pages:              [][]*drive.File{file_list},
-		cursor:             0,
-		user:               user_name.User,
-		srv:                srv,
-		pageCount:          1,
-		nextPageToken:      nextToken,
-		previousPageTokens: []string{},
-		finalPage:          false,
-		width:              0,
-		height:             0,
-		currentFolderId:    folderId,
-		navigationStack:    []NavigationState{},
-		isSearching:        false,
-		searchQuery:        "",
-		searchModel:        nil,
-		isTyping:           false,
+		listing:         root,
+		user:            user,
+		ctx:             ctx,
+		client:          c,
+		resolver:        files.NewResolver(c),
+		rootId:          myDrive.Id,
+		width:           0,
+		height:          0,
+		navigationStack: ancestorListings(root.path),
+		details:         map[string]*fileDetails{},
+		children:        map[string]*folderChildren{},
+		folderPaths:     map[string]string{},
+		tree:            treeState{expanded: map[string]bool{}},
+		marked:          map[string]*drive.File{},
+		store:           store,
+		err:             err,
+		isSearching:     false,
+		searchQuery:     "",
+		searchModel:     nil,
+		isTyping:        false,
 	}
 }
 
@@ -48,160 +99,250 @@ func (m gModel) Init() tea.Cmd {
 }
 
 func (m gModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmd := m.update(msg)
+
+	if m.filter != nil && m.filter.sourceId != m.unfilteredListing().id {
+		m.filter = nil
+	}
+	if m.treeMode && m.locationPicker == nil {
+		m.scrollTree(m.syncTree())
+	}
+	current := m.activeListing()
+	current.scroll(m.listHeight())
+
+	return m, tea.Batch(cmd, m.FetchMore(), m.FetchDetails(), m.FetchPreview(), m.FetchTree(), m.FetchLocations())
+}
+
+func (m *gModel) update(msg tea.Msg) tea.Cmd {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 
-	case tea.KeyMsg:
-		if m.isSearching {
-			switch msg.String() {
-			case "ctrl+c":
-				return m, tea.Quit
-			case "enter":
-				if m.searchQuery != "" {
-					err := m.Search()
-					if err != nil {
-						log.Fatal("Search error:", err)
-					}
-					m.isTyping = false
-				}
-			case "backspace":
-				if len(m.searchQuery) > 0 {
-					m.searchQuery = m.searchQuery[:len(m.searchQuery)-1]
-				}
+	case tea.MouseMsg:
+		return m.handleMouse(msg)
 
-			case "esc":
-				m.isSearching = false
-				m.searchQuery = ""
-			case "/":
-				m.searchQuery = ""
-				m.isTyping = true
+	case folderLoadedMsg:
+		if m.finishRequest(msg.seq) {
+			m.ShowFolder(msg.listing)
+		}
 
-			default:
-				if len(msg.String()) == 1 {
-					m.searchQuery += msg.String()
-				}
+	case pageLoadedMsg:
+		m.ShowPage(msg)
 
-			}
+	case locationsLoadedMsg:
+		if m.finishRequest(msg.seq) {
+			m.ShowLocationPicker(msg.locations)
+		}
+
+	case locationOpenedMsg:
+		if m.finishRequest(msg.seq) {
+			m.ShowLocation(msg.listing)
+		}
+
+	case detailsLoadedMsg:
+		m.details[msg.id] = &fileDetails{file: msg.file, paths: msg.paths, err: msg.err}
+
+	case folderPathLoadedMsg:
+		m.folderPaths[msg.id] = msg.path
 
+	case subtreeLoadedMsg:
+		return m.ShowSubtreeResults(msg)
+
+	case childrenLoadedMsg:
+		m.ShowChildren(msg)
+
+	case listingReloadedMsg:
+		if m.finishRequest(msg.seq) {
+			m.ShowReloaded(msg)
 		}
-		var currentFiles []*drive.File
-		var currentCursor *int
 
-		if m.searchModel != nil {
-			currentFiles = m.searchModel.files
-			currentCursor = &m.searchModel.cursor
+	case searchIdleMsg:
+		return m.SearchIdle(msg)
+
+	case searchLoadedMsg:
+		if m.finishRequest(msg.seq) {
+			msg.listing.id = m.newListingId()
+			m.searchModel = &msg.listing
+			m.isSearching = true
+			m.status = "Query: " + msg.listing.query
+		}
+
+	case downloadedMsg:
+		m.downloads--
+		if msg.err != nil {
+			m.err = msg.err
 		} else {
-			currentFiles = m.files
-			currentCursor = &m.cursor
+			m.status = fmt.Sprintf("Downloaded %s", msg.path)
+		}
+
+	case errMsg:
+		if m.finishRequest(msg.seq) {
+			m.err = msg.err
 		}
-		if len(currentFiles) == 0 {
-			return m, nil
+
+	case tea.KeyMsg:
+		m.err = nil
+		m.status = ""
+
+		if m.filter != nil {
+			return m.filterKey(msg)
+		}
+		if m.selectPrompt != nil {
+			return m.selectKey(msg)
+		}
+		if m.savePrompt != nil {
+			return m.saveKey(msg)
+		}
+
+		if m.isTyping {
+			return m.searchKey(msg)
+		}
+
+		current := m.activeListing()
+		current.stalled = false
+
+		if m.treeMode && m.locationPicker == nil {
+			if cmd, ok := m.treeKey(msg.String()); ok {
+				return cmd
+			}
 		}
 
 		switch msg.String() {
 
 		case "ctrl+c", "q":
-			return m, tea.Quit
+			return tea.Quit
 
 		case "up", "k":
-			*currentCursor--
-			if *currentCursor < 0 {
-				*currentCursor = len(currentFiles) - 1
-			}
+			current.moveCursor(-1)
 		case "down", "j":
-			*currentCursor++
-			if *currentCursor > len(currentFiles)-1 {
-				*currentCursor = 0
+			current.moveCursor(1)
+		case "pgup", "ctrl+u":
+			current.moveCursor(-m.listHeight())
+		case "pgdown", "ctrl+d":
+			current.moveCursor(m.listHeight())
+		case "home", "g":
+			current.moveCursor(-len(current.files))
+		case "end", "G":
+			current.moveCursor(len(current.files))
+		case "enter":
+			return m.OpenSelected()
+		case "esc":
+			if m.rangeStart != nil {
+				m.rangeStart = nil
+			} else if m.locationPicker != nil {
+				m.cancelPending()
+				m.locationPicker = nil
+			} else if m.isSearching {
+				m.cancelPending()
+				m.cancelSearch()
+				m.isSearching = false
+				m.searchModel = nil
+				m.searchQuery = ""
+			}
+		case "p":
+			if m.locationPicker == nil {
+				return m.OpenParent()
+			}
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			if m.locationPicker == nil {
+				return m.JumpToAncestor(int(msg.Runes[0] - '1'))
 			}
+		case "backspace":
+			m.cancelPending()
+			if m.locationPicker != nil {
+				m.locationPicker = nil
+				break
+			}
+			return m.GoBack()
+		case "s":
+			return m.SetSort(m.sort.next())
+		case "S":
+			s := m.sort
+			s.descending = !s.descending
+			return m.SetSort(s)
+		case "F":
+			s := m.sort
+			s.foldersFirst = !s.foldersFirst
+			return m.SetSort(s)
+		case "i":
+			m.showDetails = !m.showDetails
+		case "m":
+			m.millerMode = !m.millerMode
+			m.treeMode = false
+		case "t":
+			m.treeMode = !m.treeMode
+			m.millerMode = false
 		case "right", "l":
-			if m.isSearching && m.searchModel != nil {
-				if m.searchModel.nextPageToken != "" {
-					err := m.LoadNextSearchPage()
-					if err != nil {
-						log.Fatal("Error loading next search page:", err)
-					}
-				} else {
-					m.searchModel.finalPage = true
-				}
-			} else {
-				if m.nextPageToken != "" {
-					err := m.LoadNextPage()
-					if err != nil {
-						log.Fatal("Error loading next page:", err)
-					}
-				} else {
-					m.finalPage = true
+			if m.millerMode {
+				if f := current.selected(); f != nil && isFolder(f) && m.locationPicker == nil {
+					return m.OpenFolder(f.Id)
 				}
 			}
-
 		case "left", "h":
-			if m.isSearching && m.searchModel != nil {
-				m.searchModel.finalPage = false
-				if len(m.searchModel.previousPageTokens) > 0 {
-					err := m.LoadSearchCachedPage(m.searchModel.pageCount - 1)
-					if err != nil {
-						log.Fatal(err.Error())
-					}
-				}
-			} else {
-				m.finalPage = false
-				if len(m.previousPageTokens) > 0 {
-					err := m.LoadCachedPage(m.pageCount - 1)
-					if err != nil {
-						log.Fatal(err.Error())
-					}
-				}
+			if m.millerMode && m.locationPicker == nil && !m.isSearching {
+				return m.OpenParent()
 			}
-		case "enter":
-			mimeType := currentFiles[*currentCursor].MimeType
-			if mimeType == "application/vnd.google-apps.folder" {
-				m.OpenFolder(currentFiles[*currentCursor].Id)
-
-			} else {
-				files.DownloadFile(m.srv, currentFiles[*currentCursor].Id)
+		case "f":
+			if m.locationPicker == nil {
+				m.StartFilter()
 			}
-		case "backspace":
-			if err := m.RestorePreviousState(); err != nil {
-				log.Fatal(err.Error())
+		case " ":
+			if m.locationPicker == nil {
+				m.ToggleMark()
+			}
+		case "v":
+			if m.locationPicker == nil {
+				m.ToggleRange()
+			}
+		case "*":
+			if m.locationPicker == nil {
+				m.InvertMarks()
+			}
+		case "+", "-":
+			if m.locationPicker == nil {
+				m.selectPrompt = &selectPrompt{unmark: msg.String() == "-"}
+			}
+		case "u":
+			clear(m.marked)
+			m.rangeStart = nil
+		case "d":
+			if m.locationPicker == nil {
+				return m.DownloadMarked()
 			}
-		case "/":
+		case "D":
+			return m.OpenLocationPicker()
+		case "/", "?":
+			m.cancelPending()
+			m.cancelSearch()
+			m.locationPicker = nil
 			m.isSearching = true
+			m.searchContent = msg.String() == "?"
 			m.searchQuery = ""
 			m.searchModel = nil
 			m.isTyping = true
+			m.history = historyCursor{index: len(m.store.History)}
+		case "w":
+			m.StartSaveSearch()
 
 		}
 	}
 
-	return m, nil
+	return nil
 }
 
-func (m gModel) View() string {
-	var files []*drive.File
-	var cursorNum int
-	var pageCount int
-	var finalPage bool
-	var breadcrumb []string
-
-	breadcrumb = m.breadcrumb
-	if m.isSearching && m.searchModel != nil {
-		files = m.searchModel.files
-		cursorNum = m.searchModel.cursor
-		pageCount = m.searchModel.pageCount
-		finalPage = m.searchModel.finalPage
-	} else {
-		files = m.files
-		cursorNum = m.cursor
-		pageCount = m.pageCount
-		finalPage = m.finalPage
-	}
-	maxNameLen := 0
-	for _, f := range files {
-		if len(f.Name) > maxNameLen {
-			maxNameLen = len(f.Name)
-		}
+// chromeHeight is the number of lines View uses around the file list.
+const chromeHeight = 11
+
+// breadcrumbSeparator is drawn between the segments of the breadcrumb.
+const breadcrumbSeparator = " > "
+
+// headerBox renders the boxed header with the user, the breadcrumb and the
+// sort order. The breadcrumb is on its third line.
+func (m gModel) headerBox() string {
+	breadcrumb := crumbNames(m.listing.path)
+	if m.locationPicker != nil {
+		breadcrumb = []string{m.locationPicker.name}
 	}
 
 	breadcrumbStyle := lipgloss.NewStyle().
@@ -209,6 +350,16 @@ func (m gModel) View() string {
 		Border(lipgloss.NormalBorder(), true).
 		Padding(0, 1)
 
+	breadcrumb_string := fmt.Sprintf("%s (%s)\n", m.user.DisplayName, m.user.EmailAddress)
+	breadcrumb_string += strings.Join(breadcrumb, breadcrumbSeparator)
+	breadcrumb_string += "\nSort: " + m.activeListing().orderString()
+
+	return breadcrumbStyle.Render(breadcrumb_string)
+}
+
+func (m gModel) View() string {
+	current := m.activeListing()
+
 	contentStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#9AA0A6")).
 		Padding(0, 0, 1).Bold(true)
@@ -217,57 +368,133 @@ func (m gModel) View() string {
 		Foreground(lipgloss.Color("#9AA0A6")).
 		Padding(0, 0, 1).Bold(true)
 
-	breadcrumb_string := fmt.Sprintf("%s (%s)\n", m.user.DisplayName, m.user.EmailAddress)
-
-	for i, v := range breadcrumb {
-		if i > 0 {
-			breadcrumb_string += " > "
-		}
-		breadcrumb_string += v
+	marked := m.markedSet()
+	if m.locationPicker != nil {
+		marked = nil
 	}
-
-	file_string := ""
-
-	for i, f := range files {
-		icon := utils.GetFileIcon(f.Name, f.MimeType)
-
-		cursor := " "
-		if cursorNum == i {
-			cursor = ">"
-		}
-
-		file_string += fmt.Sprintf("\n%s %s %-*s", cursor, icon, maxNameLen, f.Name)
+	var location func(*drive.File) string
+	if current.showPaths || current.subtree {
+		location = m.hitLocation
 	}
+	file_string := renderRows(current, m.listHeight(), marked, location)
 
-	var page_string string
-	if finalPage {
-		page_string = fmt.Sprintf("< Page: %d", pageCount)
-	} else if pageCount == 1 {
-		page_string = fmt.Sprintf("Page: %d >", pageCount)
-	} else {
-		page_string = fmt.Sprintf("< Page: %d >", pageCount)
+	page_string := "0 items"
+	if len(current.files) > 0 {
+		page_string = fmt.Sprintf("%d/%d", current.cursor+1, len(current.files))
+	}
+	if current.hasMore() {
+		page_string += "+"
+	}
+	if len(m.marked) > 0 {
+		page_string += fmt.Sprintf("  %d marked", len(m.marked))
+	}
+	if m.rangeStart != nil {
+		page_string += "  (range)"
 	}
 	breadcrumbBar := lipgloss.PlaceHorizontal(
 		m.width,
 		lipgloss.Center,
-		breadcrumbStyle.Render(breadcrumb_string),
+		m.headerBox(),
 	)
 
 	content := contentStyle.Render(file_string)
+	if m.millerMode {
+		content = contentStyle.Render(m.renderMiller())
+	} else if m.treeMode && m.locationPicker == nil {
+		content = contentStyle.Render(m.renderTree())
+	}
+	if m.showDetails {
+		if m.detailsBeside() {
+			content = lipgloss.JoinHorizontal(lipgloss.Top,
+				lipgloss.NewStyle().Width(max(0, m.width-detailsWidth-4)).Render(content),
+				m.renderDetails(),
+			)
+		} else {
+			content = lipgloss.JoinVertical(lipgloss.Left, content, m.renderDetails())
+		}
+	}
 	page := lipgloss.PlaceHorizontal(
 		lipgloss.Width(breadcrumbBar),
 		lipgloss.Center,
 		pageStyle.Render(page_string),
 	)
 
-	if m.isTyping {
-		// Show search input at bottom
-		searchInput := fmt.Sprintf("Search: %s_", m.searchQuery)
+	status := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#9AA0A6")).
+		Render(m.statusString())
+	if m.err != nil {
+		status = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FF5F5F")).
+			Render("Error: " + m.err.Error())
+	}
+
+	if m.filter != nil {
+		filterInput := fmt.Sprintf("Filter: %s_  (%d/%d)",
+			m.filter.query, len(current.files), len(m.unfilteredListing().files))
+		return lipgloss.JoinVertical(lipgloss.Left,
+			breadcrumbBar,
+			content,
+			page,
+			status,
+			lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFF00")).Render(filterInput),
+		)
+	}
+
+	if m.selectPrompt != nil {
+		action := "Mark"
+		if m.selectPrompt.unmark {
+			action = "Unmark"
+		}
+		selectInput := fmt.Sprintf("%s matching: %s_", action, m.selectPrompt.pattern)
+		return lipgloss.JoinVertical(lipgloss.Left,
+			breadcrumbBar,
+			content,
+			page,
+			status,
+			lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFF00")).Render(selectInput),
+		)
+	}
+
+	if m.savePrompt != nil {
+		saveInput := fmt.Sprintf("Save search as: %s_", m.savePrompt.name)
 		return lipgloss.JoinVertical(lipgloss.Left,
 			breadcrumbBar,
 			content,
 			page,
+			status,
+			lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFF00")).Render(saveInput),
+		)
+	}
+
+	if m.isTyping {
+		prompt := "Search names"
+		if m.searchContent {
+			prompt = "Search content"
+		}
+		searchInput := fmt.Sprintf("%s: %s_  (tab to switch)", prompt, m.searchQuery)
+		// The status line shows how the query is understood while typing.
+		if m.searchQuery != "" {
+			if s, err := parseSearch(m.searchQuery, m.listing.folderId, m.searchContent); err != nil {
+				status = lipgloss.NewStyle().
+					Foreground(lipgloss.Color("#FF5F5F")).
+					Render("Error: " + err.Error())
+			} else {
+				query := "Query: " + s.query
+				if m.loading || m.searchModel != nil && m.searchModel.fetching {
+					query += "  (searching…)"
+				}
+				status = lipgloss.NewStyle().
+					Foreground(lipgloss.Color("#9AA0A6")).
+					Render(query)
+			}
+		}
+		// Results appear under the prompt as they arrive.
+		return lipgloss.JoinVertical(lipgloss.Left,
+			breadcrumbBar,
 			lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFF00")).Render(searchInput),
+			status,
+			content,
+			page,
 		)
 	}
 	// Layout
@@ -276,5 +503,68 @@ func (m gModel) View() string {
 		breadcrumbBar,
 		content,
 		page,
+		status,
 	)
 }
+
+var locationStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#5F87AF")).Bold(false)
+
+// renderRows renders the visible part of l, one file per line, flagging
+// the files whose ids are in marked and following each name with its
+// location when location is set.
+func renderRows(l *listing, rows int, marked map[string]bool, location func(*drive.File) string) string {
+	start := l.offset
+	end := min(start+rows, len(l.files))
+	files := l.files[start:end]
+
+	maxNameLen := 0
+	for _, f := range files {
+		if len(f.Name) > maxNameLen {
+			maxNameLen = len(f.Name)
+		}
+	}
+
+	file_string := ""
+
+	for i, f := range files {
+		icon := utils.GetFileIcon(f.Name, f.MimeType)
+
+		cursor := " "
+		if l.cursor == start+i {
+			cursor = ">"
+		}
+		if marked[f.Id] {
+			cursor += " *"
+		} else if marked != nil {
+			cursor += "  "
+		}
+
+		name := f.Name
+		if positions, ok := l.highlights[f.Id]; ok {
+			name = highlightName(f.Name, positions)
+		}
+		file_string += fmt.Sprintf("\n%s %s %s%s", cursor, icon, name, strings.Repeat(" ", maxNameLen-len(f.Name)))
+		if location != nil {
+			file_string += "  " + locationStyle.Render(location(f))
+		}
+	}
+
+	return file_string
+}
+
+func (m gModel) statusString() string {
+	status := m.status
+	if m.loading || m.activeListing().fetching {
+		if status != "" {
+			status += "  "
+		}
+		status += "Loading..."
+	}
+	if m.downloads > 0 {
+		if status != "" {
+			status += "  "
+		}
+		status += fmt.Sprintf("Downloading %d file(s)...", m.downloads)
+	}
+	return status
+}
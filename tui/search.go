@@ -1,91 +1,184 @@
 package tui
 
 import (
-	"fmt"
+	"context"
+	"strings"
+	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"google.golang.org/api/drive/v3"
 )
 
-type searchModel struct {
-	files              []*drive.File
-	pages              [][]*drive.File
-	cursor             int
-	pageCount          int
-	nextPageToken      string
-	previousPageTokens []string
-	finalPage          bool
-}
+// pendingPath marks a folder path that is being looked up.
+const pendingPath = "…"
 
-func (m *gModel) LoadNextSearchPage() error {
-	q := fmt.Sprintf("name contains '%s'", m.searchQuery)
+// searchDelay is how long typing has to pause before the query runs.
+const searchDelay = 300 * time.Millisecond
 
-	call := m.srv.Files.List().
-		PageSize(10).
-		OrderBy("name").
-		Q(q).
-		Fields("nextPageToken, files(id, name, mimeType)")
+type folderPathLoadedMsg struct {
+	id   string
+	path string
+}
 
-	if m.searchModel.nextPageToken != "" {
-		call = call.PageToken(m.searchModel.nextPageToken)
-	}
+// searchIdleMsg is sent searchDelay after an edit of the search query.
+type searchIdleMsg struct {
+	edit int
+}
 
-	res, err := call.Do()
-	if err != nil {
-		return err
+// searchKey handles a key typed at the search prompt. Every edit cancels
+// the search in flight and restarts the wait before the query runs.
+func (m *gModel) searchKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "enter":
+		if m.searchQuery == "" {
+			return nil
+		}
+		cmd, err := m.Search()
+		if err != nil {
+			m.err = err
+			return nil
+		}
+		m.isTyping = false
+		m.RememberSearch()
+		return cmd
+	case "up", "down":
+		delta := 1
+		if msg.String() == "up" {
+			delta = -1
+		}
+		if !m.RecallSearch(delta) {
+			return nil
+		}
+	case "backspace":
+		if len(m.searchQuery) == 0 {
+			return nil
+		}
+		m.searchQuery = m.searchQuery[:len(m.searchQuery)-1]
+	case "esc":
+		m.isTyping = false
+		m.searchEdits++
+		if m.searchModel == nil {
+			m.cancelPending()
+			m.cancelSearch()
+			m.isSearching = false
+		}
+		return nil
+	case "tab":
+		m.searchContent = !m.searchContent
+	default:
+		if len(msg.String()) != 1 {
+			return nil
+		}
+		m.searchQuery += msg.String()
 	}
 
-	m.searchModel.previousPageTokens = append(m.searchModel.previousPageTokens, m.searchModel.nextPageToken)
-	m.searchModel.files = res.Files
-	m.searchModel.nextPageToken = res.NextPageToken
-	m.searchModel.cursor = 0
-	m.searchModel.pages = append(m.searchModel.pages, res.Files)
-	m.searchModel.pageCount++
-
-	return nil
+	// Results still on their way are for a query that no longer applies.
+	m.cancelPending()
+	m.cancelSearch()
+	m.searchEdits++
+	edit := m.searchEdits
+	return tea.Tick(searchDelay, func(time.Time) tea.Msg {
+		return searchIdleMsg{edit: edit}
+	})
 }
 
-func (m *gModel) LoadSearchCachedPage(currPage int) error {
-	index := currPage - 1
-
-	if index < 0 || index >= len(m.searchModel.pages) {
+// SearchIdle runs the query once typing has paused, unless it was edited
+// again since. Queries that do not parse are left to the status line.
+func (m *gModel) SearchIdle(msg searchIdleMsg) tea.Cmd {
+	if !m.isTyping || msg.edit != m.searchEdits {
 		return nil
 	}
-	m.searchModel.files = m.searchModel.pages[index]
-	m.searchModel.pageCount--
-	m.searchModel.nextPageToken = m.searchModel.previousPageTokens[index]
-	m.searchModel.cursor = 0
-
-	return nil
+	if m.searchQuery == "" {
+		m.cancelPending()
+		m.cancelSearch()
+		m.searchModel = nil
+		return nil
+	}
+	cmd, err := m.Search()
+	if err != nil {
+		return nil
+	}
+	return cmd
+}
 
+// cancelSearch aborts the requests of the last search still in flight.
+func (m *gModel) cancelSearch() {
+	if m.searchCancel != nil {
+		m.searchCancel()
+		m.searchCancel = nil
+	}
 }
 
-func (m *gModel) SaveSearchModel(r *drive.FileList) {
-	m.searchModel = &searchModel{
-		files:              r.Files,
-		pages:              [][]*drive.File{r.Files},
-		cursor:             0,
-		pageCount:          1,
-		nextPageToken:      r.NextPageToken,
-		previousPageTokens: []string{},
-		finalPage:          r.NextPageToken == "",
+// Search runs the query typed at the search prompt, cancelling the previous
+// search. It returns an error without searching when the query cannot be
+// parsed.
+func (m *gModel) Search() (tea.Cmd, error) {
+	s, err := parseSearch(m.searchQuery, m.listing.folderId, m.searchContent)
+	if err != nil {
+		return nil, err
 	}
 
-}
+	m.cancelSearch()
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.searchCancel = cancel
+	if s.subtree {
+		return m.SearchSubtree(ctx, s)
+	}
 
-func (m *gModel) Search() error {
+	seq := m.startRequest()
+	search := m.typedSearch()
+	l := searchListing(m.searchQuery, s, m.listing.driveId)
+	l.search = &search
+	l.sort = m.sort
+	if m.searchContent {
+		l.name = "Content: " + m.searchQuery
+	}
 
-	r, err := m.srv.Files.List().PageSize(10).
-		OrderBy("name").
-		Q(fmt.Sprintf("name contains '%s'", m.searchQuery)).
-		Fields("nextPageToken, files(id, name, mimeType)").Do()
+	return listCmd(ctx, m.client, seq, l.options(), func(res *drive.FileList) tea.Msg {
+		l.addPage(res)
+		return searchLoadedMsg{seq: seq, listing: l}
+	}), nil
+}
 
-	if err != nil {
-		m.RestorePreviousState()
-		return err
+// FetchLocations looks up the paths of the folders containing the visible
+// results of a search.
+func (m *gModel) FetchLocations() tea.Cmd {
+	l := m.activeListing()
+	if !l.showPaths {
+		return nil
 	}
-	m.SaveSearchModel(r)
 
-	m.isSearching = true
+	var cmds []tea.Cmd
+	end := min(l.offset+m.listHeight(), len(l.files))
+	for _, f := range l.files[l.offset:end] {
+		if len(f.Parents) == 0 {
+			continue
+		}
+		id := f.Parents[0]
+		if _, ok := m.folderPaths[id]; ok {
+			continue
+		}
+		m.folderPaths[id] = pendingPath
+
+		ctx, r := m.ctx, m.resolver
+		cmds = append(cmds, func() tea.Msg {
+			// Folders outside My Drive and the shared drives have no path.
+			paths, _ := r.Paths(ctx, id)
+			return folderPathLoadedMsg{id: id, path: strings.Join(paths, ", ")}
+		})
+	}
+	return tea.Batch(cmds...)
+}
 
-	return nil
+// hitLocation is the path of the folder containing a search result.
+func (m *gModel) hitLocation(f *drive.File) string {
+	if l := m.activeListing(); l.locations != nil {
+		return l.locations[f.Id]
+	}
+	if len(f.Parents) == 0 {
+		return ""
+	}
+	return m.folderPaths[f.Parents[0]]
 }
@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"context"
+	"testing"
+
+	"drivebrowser/client"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/api/drive/v3"
+)
+
+// newTestModel starts the browser at the root of a small fake Drive:
+//
+//	Work/
+//	  Reports/
+//	    2026/
+//	      report.txt
+//	  Wx notes.txt
+func newTestModel(t *testing.T) *gModel {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	c := client.NewFakeClient(&drive.User{DisplayName: "Tester", EmailAddress: "t@example.com"})
+	work := c.AddFolder("root", "Work")
+	reports := c.AddFolder(work, "Reports")
+	year := c.AddFolder(reports, "2026")
+	c.AddFile(&drive.File{Name: "report.txt", MimeType: "text/plain", Parents: []string{year}}, []byte("r"))
+	c.AddFile(&drive.File{Name: "Wx notes.txt", MimeType: "text/plain", Parents: []string{work}}, []byte("n"))
+
+	m := InitialModel(context.Background(), c, "root")
+	if m.err != nil {
+		t.Fatal(m.err)
+	}
+	return &m
+}
+
+func typeKeys(m *gModel, keys ...string) tea.Cmd {
+	var cmd tea.Cmd
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "backspace":
+			msg = tea.KeyMsg{Type: tea.KeyBackspace}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		cmd = m.update(msg)
+	}
+	return cmd
+}
+
+func TestEditDropsStaleResults(t *testing.T) {
+	m := newTestModel(t)
+
+	typeKeys(m, "/", "W")
+	search := m.update(searchIdleMsg{edit: m.searchEdits})
+	if search == nil {
+		t.Fatal("no search ran after typing paused")
+	}
+
+	typeKeys(m, "x")
+	m.update(search())
+
+	if m.searchModel != nil {
+		t.Errorf("results for %q shown while the prompt reads %q", m.searchModel.name, m.searchQuery)
+	}
+}
+
+func TestIdleSearchShowsResults(t *testing.T) {
+	m := newTestModel(t)
+
+	typeKeys(m, "/", "W", "x")
+	search := m.update(searchIdleMsg{edit: m.searchEdits})
+	m.update(search())
+
+	if m.searchModel == nil || m.searchModel.name != "Search: Wx" {
+		t.Fatalf("searchModel = %+v, want the results for Wx", m.searchModel)
+	}
+	if len(m.searchModel.files) != 1 || m.searchModel.files[0].Name != "Wx notes.txt" {
+		t.Errorf("found %v", m.searchModel.files)
+	}
+}
+
+// openFromSearch searches for folder names containing query and opens the
+// first result.
+func openFromSearch(t *testing.T, m *gModel, query string) {
+	t.Helper()
+	typeKeys(m, "/")
+	m.searchQuery = query
+	m.update(typeKeys(m, "enter")())
+	if m.searchModel == nil || len(m.searchModel.files) == 0 {
+		t.Fatalf("no results for %q", query)
+	}
+	m.update(typeKeys(m, "enter")())
+	if m.isSearching {
+		t.Fatalf("still searching after opening %s", m.listing.name)
+	}
+}
+
+func TestBackReturnsToSearchResults(t *testing.T) {
+	m := newTestModel(t)
+
+	openFromSearch(t, m, "2026 type:folder")
+	if got := crumbNames(m.listing.path); len(got) != 4 || got[3] != "2026" {
+		t.Fatalf("breadcrumb = %q", got)
+	}
+
+	m.update(m.GoBack())
+	if !m.isSearching || m.searchModel == nil || m.searchQuery != "2026 type:folder" {
+		t.Errorf("back did not return to the search, searching %v for %q", m.isSearching, m.searchQuery)
+	}
+}
+
+func TestJumpLeavesSearchResultsBehind(t *testing.T) {
+	m := newTestModel(t)
+
+	openFromSearch(t, m, "2026 type:folder")
+	m.update(m.JumpToAncestor(0))
+	if m.listing.folderId != client.FakeRootId {
+		t.Fatalf("jumped to %s, want My Drive", m.listing.name)
+	}
+
+	m.update(typeKeys(m, "enter")()) // Work
+	if m.listing.name != "Work" {
+		t.Fatalf("opened %s, want Work", m.listing.name)
+	}
+	m.update(m.GoBack())
+	if m.isSearching || m.searchModel != nil {
+		t.Errorf("back from Work reopened the search for %q", m.searchQuery)
+	}
+	if m.listing.folderId != client.FakeRootId {
+		t.Errorf("back from Work went to %s", m.listing.name)
+	}
+}
+
+func names(files []*drive.File) []string {
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// run delivers the results of cmd, which must not be a batch.
+func run(m *gModel, cmd tea.Cmd) {
+	if cmd != nil {
+		m.update(cmd())
+	}
+}
+
+func TestParentOfPickedMyDrive(t *testing.T) {
+	m := newTestModel(t)
+
+	run(m, typeKeys(m, "D"))
+	run(m, typeKeys(m, "enter")) // My Drive
+	run(m, typeKeys(m, "enter")) // Work
+	if m.listing.name != "Work" || len(m.navigationStack) != 1 {
+		t.Fatalf("in %s with %d folders of history", m.listing.name, len(m.navigationStack))
+	}
+
+	run(m, typeKeys(m, "p"))
+	if m.listing.folderId != client.FakeRootId || len(m.navigationStack) != 0 {
+		t.Errorf("p went to %s with %d folders of history, want My Drive with none",
+			m.listing.name, len(m.navigationStack))
+	}
+}
@@ -0,0 +1,253 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"drivebrowser/client"
+)
+
+// searchTypes maps the values of type: to Drive mime types. Values ending
+// in a slash match every subtype.
+var searchTypes = map[string]string{
+	"folder":       "application/vnd.google-apps.folder",
+	"doc":          "application/vnd.google-apps.document",
+	"document":     "application/vnd.google-apps.document",
+	"sheet":        "application/vnd.google-apps.spreadsheet",
+	"spreadsheet":  "application/vnd.google-apps.spreadsheet",
+	"slides":       "application/vnd.google-apps.presentation",
+	"presentation": "application/vnd.google-apps.presentation",
+	"drawing":      "application/vnd.google-apps.drawing",
+	"form":         "application/vnd.google-apps.form",
+	"pdf":          "application/pdf",
+	"zip":          "application/zip",
+	"image":        "image/",
+	"video":        "video/",
+	"audio":        "audio/",
+	"text":         "text/",
+}
+
+// searchTimeFields maps the date filters to Drive time fields.
+var searchTimeFields = map[string]string{
+	"modified": "modifiedTime",
+	"created":  "createdTime",
+	"viewed":   "viewedByMeTime",
+}
+
+// parsedSearch is a search prompt translated to a Drive query.
+type parsedSearch struct {
+	query    string
+	trashed  bool
+	fullText bool
+	subtree  bool
+}
+
+// parseSearch translates the text typed at the search prompt. Plain words
+// and "quoted phrases" match names; typed filters are
+//
+//	name:x  fulltext:x  type:pdf  owner:me  starred:true  in:current
+//	in:below  in:trash  modified:>2026-01-01  created:<=2025-12-31
+//	viewed:2026-03-01
+//
+// and any term but in:below can be negated with a leading "-", e.g.
+// -type:folder. Trashed files are left out unless the query asks for them
+// with in:trash or trashed:true; -trashed states the default explicitly.
+// folderId is the folder in:current refers to; in:below searches it and
+// every folder below it. In content mode plain words and phrases match the
+// content of files instead of their names.
+func parseSearch(text string, folderId string, content bool) (parsedSearch, error) {
+	terms, err := splitSearch(text)
+	if err != nil {
+		return parsedSearch{}, err
+	}
+	if len(terms) == 0 {
+		return parsedSearch{}, fmt.Errorf("empty search")
+	}
+
+	var clauses []string
+	trashed := false
+	fullText := false
+	subtree := false
+	for _, term := range terms {
+		negated := false
+		if len(term) > 1 && strings.HasPrefix(term, "-") {
+			negated = true
+			term = term[1:]
+		}
+
+		if term == "trashed" {
+			// -trashed and trashed are flags rather than names.
+			trashed = !negated
+			continue
+		}
+
+		key, value, typed := strings.Cut(term, ":")
+		if !typed || strings.HasPrefix(term, `"`) {
+			key, value = "name", term
+			if content {
+				key = "fulltext"
+			}
+		}
+		value = unquote(value)
+		if value == "" {
+			return parsedSearch{}, fmt.Errorf("%s: missing value", term)
+		}
+
+		var clause string
+		switch key {
+		case "name":
+			clause = client.Contains("name", value)
+		case "fulltext":
+			clause = client.Contains("fullText", value)
+			fullText = true
+		case "type":
+			mimeType, ok := searchTypes[strings.ToLower(value)]
+			switch {
+			case ok && strings.HasSuffix(mimeType, "/"):
+				clause = client.Contains("mimeType", mimeType)
+			case ok:
+				clause = client.Compare("mimeType", "=", mimeType)
+			case strings.Contains(value, "/"):
+				clause = client.Compare("mimeType", "=", value)
+			default:
+				return parsedSearch{}, fmt.Errorf("%s: unknown type %q", term, value)
+			}
+		case "owner":
+			clause = client.In(value, "owners")
+		case "starred", "trashed", "shared":
+			b, err := parseBool(value)
+			if err != nil {
+				return parsedSearch{}, fmt.Errorf("%s: %w", term, err)
+			}
+			if key == "trashed" {
+				trashed = b != negated
+				continue
+			}
+			field := key
+			if key == "shared" {
+				field = "sharedWithMe"
+			}
+			clause = client.Is(field, b)
+		case "in":
+			switch value {
+			case "current":
+				if folderId == "" {
+					return parsedSearch{}, fmt.Errorf("%s: not in a folder", term)
+				}
+				clause = client.InParents(folderId)
+			case "below":
+				if negated {
+					return parsedSearch{}, fmt.Errorf("-%s: in:below cannot be negated", term)
+				}
+				if folderId == "" {
+					return parsedSearch{}, fmt.Errorf("%s: not in a folder", term)
+				}
+				subtree = true
+				continue
+			case "trash":
+				trashed = !negated
+				continue
+			default:
+				return parsedSearch{}, fmt.Errorf("%s: expected in:current, in:below or in:trash", term)
+			}
+		case "modified", "created", "viewed":
+			clause, err = timeClause(searchTimeFields[key], value)
+			if err != nil {
+				return parsedSearch{}, fmt.Errorf("%s: %w", term, err)
+			}
+		default:
+			return parsedSearch{}, fmt.Errorf("%s: unknown filter %q", term, key)
+		}
+
+		if negated {
+			clause = client.Not(clause)
+		}
+		clauses = append(clauses, clause)
+	}
+
+	clauses = append(clauses, client.Is("trashed", trashed))
+	return parsedSearch{query: client.And(clauses...), trashed: trashed, fullText: fullText, subtree: subtree}, nil
+}
+
+// splitSearch splits text at spaces outside of double quotes, keeping the
+// quotes in the terms.
+func splitSearch(text string) ([]string, error) {
+	var terms []string
+	var term strings.Builder
+	quoted := false
+	for _, r := range text {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			term.WriteRune(r)
+		case r == ' ' && !quoted:
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+		default:
+			term.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+	return terms, nil
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`) {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "yes":
+		return true, nil
+	case "false", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected true or false, got %q", s)
+}
+
+// timeClause compares a time field with a date such as >2026-01-01. A date
+// without an operator matches the whole day.
+func timeClause(field string, value string) (string, error) {
+	op := "="
+	for _, o := range []string{">=", "<=", ">", "<", "="} {
+		if rest, ok := strings.CutPrefix(value, o); ok {
+			op, value = o, rest
+			break
+		}
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	day := false
+	if err != nil {
+		t, err = time.Parse("2006-01-02", value)
+		day = err == nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+
+	format := func(t time.Time) string { return t.UTC().Format(time.RFC3339) }
+	switch {
+	case day && op == "=":
+		return client.And(
+			client.Compare(field, ">=", format(t)),
+			client.Compare(field, "<", format(t.AddDate(0, 0, 1))),
+		), nil
+	case day && op == ">":
+		return client.Compare(field, ">=", format(t.AddDate(0, 0, 1))), nil
+	case day && op == "<=":
+		return client.Compare(field, "<", format(t.AddDate(0, 0, 1))), nil
+	}
+	return client.Compare(field, op, format(t)), nil
+}
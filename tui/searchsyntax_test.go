@@ -0,0 +1,97 @@
+package tui
+
+import "testing"
+
+func TestParseSearch(t *testing.T) {
+	tests := []struct {
+		text    string
+		content bool
+		want    parsedSearch
+	}{
+		{text: "report", want: parsedSearch{
+			query: `name contains 'report' and trashed = false`}},
+		{text: "O'Brien", want: parsedSearch{
+			query: `name contains 'O\'Brien' and trashed = false`}},
+		{text: `"quarterly report" type:pdf`, want: parsedSearch{
+			query: `name contains 'quarterly report' and mimeType = 'application/pdf' and trashed = false`}},
+		{text: "type:image -starred:true", want: parsedSearch{
+			query: `mimeType contains 'image/' and not starred = true and trashed = false`}},
+		{text: "owner:me in:current", want: parsedSearch{
+			query: `'me' in owners and 'folder-1' in parents and trashed = false`}},
+		{text: "draft in:trash", want: parsedSearch{
+			query: `name contains 'draft' and trashed = true`, trashed: true}},
+		{text: "draft -trashed", want: parsedSearch{
+			query: `name contains 'draft' and trashed = false`}},
+		{text: "plan", content: true, want: parsedSearch{
+			query: `fullText contains 'plan' and trashed = false`, fullText: true}},
+		{text: "name:plan fulltext:launch", want: parsedSearch{
+			query: `name contains 'plan' and fullText contains 'launch' and trashed = false`, fullText: true}},
+		{text: "notes -in:current", want: parsedSearch{
+			query: `name contains 'notes' and not 'folder-1' in parents and trashed = false`}},
+		{text: "draft -in:trash", want: parsedSearch{
+			query: `name contains 'draft' and trashed = false`}},
+		{text: "notes in:below", want: parsedSearch{
+			query: `name contains 'notes' and trashed = false`, subtree: true}},
+		{text: "-type:folder modified:>=2026-01-01", want: parsedSearch{
+			query: `not mimeType = 'application/vnd.google-apps.folder' and modifiedTime >= '2026-01-01T00:00:00Z' and trashed = false`}},
+	}
+	for _, tt := range tests {
+		got, err := parseSearch(tt.text, "folder-1", tt.content)
+		if err != nil {
+			t.Errorf("parseSearch(%q): %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSearch(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseSearchErrors(t *testing.T) {
+	tests := []struct {
+		text     string
+		folderId string
+	}{
+		{"", "folder-1"},
+		{`"unterminated`, "folder-1"},
+		{"name:", "folder-1"},
+		{"type:spaceship", "folder-1"},
+		{"color:red", "folder-1"},
+		{"starred:maybe", "folder-1"},
+		{"in:somewhere", "folder-1"},
+		{"in:current", ""},
+		{"in:below", ""},
+		{"-in:below foo", "folder-1"},
+		{"modified:yesterday", "folder-1"},
+	}
+	for _, tt := range tests {
+		if got, err := parseSearch(tt.text, tt.folderId, false); err == nil {
+			t.Errorf("parseSearch(%q) = %+v, want an error", tt.text, got)
+		}
+	}
+}
+
+func TestTimeClause(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"2026-03-01", `modifiedTime >= '2026-03-01T00:00:00Z' and modifiedTime < '2026-03-02T00:00:00Z'`},
+		{">2026-03-01", `modifiedTime >= '2026-03-02T00:00:00Z'`},
+		{">=2026-03-01", `modifiedTime >= '2026-03-01T00:00:00Z'`},
+		{"<2026-03-01", `modifiedTime < '2026-03-01T00:00:00Z'`},
+		{"<=2026-03-01", `modifiedTime < '2026-03-02T00:00:00Z'`},
+		{"=2026-12-31", `modifiedTime >= '2026-12-31T00:00:00Z' and modifiedTime < '2027-01-01T00:00:00Z'`},
+		{">2026-03-01T10:00:00+02:00", `modifiedTime > '2026-03-01T08:00:00Z'`},
+	}
+	for _, tt := range tests {
+		got, err := timeClause("modifiedTime", tt.value)
+		if err != nil {
+			t.Errorf("timeClause(%q): %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("timeClause(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/api/drive/v3"
+)
+
+// selectPrompt is the pattern prompt for marking (or unmarking) every
+// loaded file whose name matches a glob.
+type selectPrompt struct {
+	pattern string
+	unmark  bool
+}
+
+// visibleFiles returns the files in the order they are shown and the
+// index of the cursor among them. Placeholder rows of the tree are nil.
+func (m *gModel) visibleFiles() ([]*drive.File, int) {
+	if m.treeMode && m.locationPicker == nil {
+		rows := m.treeRows()
+		files := make([]*drive.File, len(rows))
+		for i, r := range rows {
+			files[i] = r.file
+		}
+		return files, m.tree.cursor
+	}
+	l := m.activeListing()
+	return l.files, l.cursor
+}
+
+// ToggleMark marks or unmarks the highlighted file.
+func (m *gModel) ToggleMark() {
+	f := m.selectedFile()
+	if f == nil {
+		return
+	}
+	if _, ok := m.marked[f.Id]; ok {
+		delete(m.marked, f.Id)
+	} else {
+		m.marked[f.Id] = f
+	}
+}
+
+// ToggleRange starts a range at the cursor, or marks every file between
+// the start of the range and the cursor.
+func (m *gModel) ToggleRange() {
+	files, cursor := m.visibleFiles()
+	if m.rangeStart == nil {
+		if f := m.selectedFile(); f != nil {
+			m.rangeStart = f
+		}
+		return
+	}
+
+	for _, f := range m.rangeFiles(files, cursor) {
+		m.marked[f.Id] = f
+	}
+	m.rangeStart = nil
+}
+
+// rangeFiles returns the files between the start of the range and the
+// cursor, or nothing when the start is no longer shown.
+func (m *gModel) rangeFiles(files []*drive.File, cursor int) []*drive.File {
+	if m.rangeStart == nil || cursor < 0 {
+		return nil
+	}
+	start := -1
+	for i, f := range files {
+		if f != nil && f.Id == m.rangeStart.Id {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	var found []*drive.File
+	for _, f := range files[min(start, cursor) : max(start, cursor)+1] {
+		if f != nil {
+			found = append(found, f)
+		}
+	}
+	return found
+}
+
+// InvertMarks flips the mark of every file that is shown.
+func (m *gModel) InvertMarks() {
+	files, _ := m.visibleFiles()
+	for _, f := range files {
+		if f == nil {
+			continue
+		}
+		if _, ok := m.marked[f.Id]; ok {
+			delete(m.marked, f.Id)
+		} else {
+			m.marked[f.Id] = f
+		}
+	}
+}
+
+// MarkMatching marks, or unmarks, every shown file whose name matches the
+// glob pattern, ignoring case.
+func (m *gModel) MarkMatching(pattern string, unmark bool) error {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	count := 0
+	files, _ := m.visibleFiles()
+	for _, f := range files {
+		if f == nil {
+			continue
+		}
+		if ok, _ := path.Match(pattern, strings.ToLower(f.Name)); !ok {
+			continue
+		}
+		count++
+		if unmark {
+			delete(m.marked, f.Id)
+		} else {
+			m.marked[f.Id] = f
+		}
+	}
+
+	if unmark {
+		m.status = fmt.Sprintf("Unmarked %d file(s)", count)
+	} else {
+		m.status = fmt.Sprintf("Marked %d file(s)", count)
+	}
+	return nil
+}
+
+// markedSet returns the ids of the marked files, including the pending
+// range, for rendering.
+func (m *gModel) markedSet() map[string]bool {
+	set := map[string]bool{}
+	for id := range m.marked {
+		set[id] = true
+	}
+	files, cursor := m.visibleFiles()
+	for _, f := range m.rangeFiles(files, cursor) {
+		set[f.Id] = true
+	}
+	return set
+}
+
+// DownloadMarked downloads every marked file, or the highlighted file when
+// nothing is marked. Folders are skipped.
+func (m *gModel) DownloadMarked() tea.Cmd {
+	if len(m.marked) == 0 {
+		return m.DownloadSelected()
+	}
+	return m.downloadAll(m.markedFiles())
+}
+
+// DownloadSelected downloads the highlighted file together with every
+// marked file.
+func (m *gModel) DownloadSelected() tea.Cmd {
+	files := m.markedFiles()
+	if f := m.selectedFile(); f != nil && !isFolder(f) && m.marked[f.Id] == nil {
+		files = append(files, f)
+	}
+	return m.downloadAll(files)
+}
+
+// markedFiles returns the marked files in name order.
+func (m *gModel) markedFiles() []*drive.File {
+	files := make([]*drive.File, 0, len(m.marked))
+	for _, f := range m.marked {
+		files = append(files, f)
+	}
+	slices.SortFunc(files, func(a, b *drive.File) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name), strings.Compare(a.Id, b.Id))
+	})
+	return files
+}
+
+func (m *gModel) downloadAll(files []*drive.File) tea.Cmd {
+	var cmds []tea.Cmd
+	skipped := 0
+	for _, f := range files {
+		if isFolder(f) {
+			skipped++
+			continue
+		}
+		cmds = append(cmds, m.DownloadFile(f))
+	}
+	if skipped > 0 {
+		m.status = fmt.Sprintf("Skipped %d folder(s)", skipped)
+	}
+	return tea.Batch(cmds...)
+}
+
+// selectKey handles keys while the pattern prompt is open.
+func (m *gModel) selectKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.selectPrompt = nil
+	case "enter":
+		p := m.selectPrompt
+		m.selectPrompt = nil
+		m.err = m.MarkMatching(p.pattern, p.unmark)
+	case "backspace":
+		if p := []rune(m.selectPrompt.pattern); len(p) > 0 {
+			m.selectPrompt.pattern = string(p[:len(p)-1])
+		}
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			m.selectPrompt.pattern += string(msg.Runes)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+)
+
+type sortField int
+
+const (
+	sortByName sortField = iota
+	sortByModified
+	sortBySize
+	sortByType
+	sortByQuota
+	sortFieldCount
+)
+
+var sortFieldNames = map[sortField]string{
+	sortByName:     "name",
+	sortByModified: "modified",
+	sortBySize:     "size",
+	sortByType:     "type",
+	sortByQuota:    "quota used",
+}
+
+// sortOrder is the order chosen by the user for the session. Drive can only
+// order by some fields; size and type are sorted locally once the whole
+// listing has been fetched.
+type sortOrder struct {
+	field        sortField
+	descending   bool
+	foldersFirst bool
+}
+
+func (s sortOrder) next() sortOrder {
+	s.field = (s.field + 1) % sortFieldCount
+	return s
+}
+
+// local reports whether the order cannot be expressed as a Drive orderBy.
+func (s sortOrder) local() bool {
+	return s.field == sortBySize || s.field == sortByType
+}
+
+func (s sortOrder) orderBy() string {
+	key := "name"
+	switch s.field {
+	case sortByModified:
+		key = "modifiedTime"
+	case sortByQuota:
+		key = "quotaBytesUsed"
+	}
+	if s.descending && !s.local() {
+		key += " desc"
+	}
+	if s.foldersFirst {
+		key = "folder," + key
+	}
+	return key
+}
+
+func (s sortOrder) String() string {
+	str := sortFieldNames[s.field]
+	if s.descending {
+		str += " ↓"
+	} else {
+		str += " ↑"
+	}
+	if s.foldersFirst {
+		str += ", folders first"
+	}
+	return str
+}
+
+// sortFiles applies the order to files locally, as Drive would for
+// orderBy.
+func (s sortOrder) sortFiles(files []*drive.File) {
+	slices.SortStableFunc(files, func(a, b *drive.File) int {
+		if s.foldersFirst {
+			if c := -cmp.Compare(boolRank(isFolder(a)), boolRank(isFolder(b))); c != 0 {
+				return c
+			}
+		}
+
+		var c int
+		switch s.field {
+		case sortByModified:
+			// Drive writes every time in UTC in the same format, so the
+			// strings sort in time order.
+			c = cmp.Compare(a.ModifiedTime, b.ModifiedTime)
+		case sortByQuota:
+			c = cmp.Compare(a.QuotaBytesUsed, b.QuotaBytesUsed)
+		case sortBySize:
+			c = cmp.Compare(a.Size, b.Size)
+		case sortByType:
+			c = cmp.Compare(a.MimeType, b.MimeType)
+		}
+		if c == 0 {
+			c = cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+		if s.descending {
+			c = -c
+		}
+		return c
+	})
+}
+
+func isFolder(f *drive.File) bool {
+	return f.MimeType == "application/vnd.google-apps.folder"
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
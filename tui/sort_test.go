@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"google.golang.org/api/drive/v3"
+)
+
+func TestSortFiles(t *testing.T) {
+	files := []*drive.File{
+		{Name: "a", MimeType: "text/plain", ModifiedTime: "2026-03-01T00:00:00.000Z", Size: 30, QuotaBytesUsed: 10},
+		{Name: "B", MimeType: "application/pdf", ModifiedTime: "2026-01-01T00:00:00.000Z", Size: 10, QuotaBytesUsed: 30},
+		{Name: "c", MimeType: "application/vnd.google-apps.folder", ModifiedTime: "2026-02-01T00:00:00.000Z", QuotaBytesUsed: 20},
+	}
+	tests := []struct {
+		order sortOrder
+		want  []string
+	}{
+		{sortOrder{field: sortByName}, []string{"a", "B", "c"}},
+		{sortOrder{field: sortByName, descending: true}, []string{"c", "B", "a"}},
+		{sortOrder{field: sortByModified}, []string{"B", "c", "a"}},
+		{sortOrder{field: sortByModified, descending: true}, []string{"a", "c", "B"}},
+		{sortOrder{field: sortByQuota}, []string{"a", "c", "B"}},
+		{sortOrder{field: sortBySize}, []string{"c", "B", "a"}},
+		{sortOrder{field: sortByType}, []string{"B", "c", "a"}},
+		{sortOrder{field: sortByModified, foldersFirst: true}, []string{"c", "B", "a"}},
+	}
+	for _, tt := range tests {
+		sorted := slices.Clone(files)
+		tt.order.sortFiles(sorted)
+		if got := names(sorted); !slices.Equal(got, tt.want) {
+			t.Errorf("sorted by %v: %q, want %q", tt.order, got, tt.want)
+		}
+	}
+}
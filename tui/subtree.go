@@ -0,0 +1,180 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"drivebrowser/client"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/api/drive/v3"
+)
+
+// parentBatch is the number of folders searched by one query, to keep the
+// parents clauses of a query within Drive's limits.
+const parentBatch = 25
+
+// subtreeWalk is the state of a search of a folder and everything below
+// it. Each step lists one page, either of the subfolders or of the matches
+// in a batch of folders, so results can be shown as they arrive.
+// ctx is cancelled when the search is replaced.
+type subtreeWalk struct {
+	ctx     context.Context
+	query   string
+	driveId string
+	trashed bool
+	pending []string
+	paths   map[string]string
+	batch   []string
+	folders bool
+	token   string
+}
+
+type subtreeLoadedMsg struct {
+	listingId int
+	walk      *subtreeWalk
+	hits      []*drive.File
+	locations map[string]string
+	err       error
+}
+
+func newSubtreeWalk(ctx context.Context, folderId string, s parsedSearch, driveId string) *subtreeWalk {
+	return &subtreeWalk{
+		ctx:     ctx,
+		query:   s.query,
+		driveId: driveId,
+		trashed: s.trashed,
+		pending: []string{folderId},
+		paths:   map[string]string{folderId: "."},
+	}
+}
+
+func (w *subtreeWalk) done() bool {
+	return len(w.batch) == 0 && len(w.pending) == 0
+}
+
+// step lists the next page of the walk. It returns the matches found, with
+// the path of the folder holding each one relative to the root of the walk.
+func (w *subtreeWalk) step(c client.DriveClient) ([]*drive.File, map[string]string, error) {
+	if len(w.batch) == 0 {
+		n := min(parentBatch, len(w.pending))
+		w.batch, w.pending = w.pending[:n], w.pending[n:]
+		w.folders = true
+		w.token = ""
+	}
+
+	var parents []string
+	for _, id := range w.batch {
+		parents = append(parents, client.InParents(id))
+	}
+	query := client.And(client.Or(parents...), w.query)
+	if w.folders {
+		query = client.And(client.Or(parents...),
+			client.Compare("mimeType", "=", "application/vnd.google-apps.folder"),
+			client.Is("trashed", w.trashed))
+	}
+
+	res, err := c.List(w.ctx, client.ListOptions{
+		Query:     query,
+		PageSize:  pageSize,
+		PageToken: w.token,
+		DriveId:   w.driveId,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	w.token = res.NextPageToken
+
+	if w.folders {
+		for _, f := range res.Files {
+			if _, seen := w.paths[f.Id]; seen || len(f.Parents) == 0 {
+				continue
+			}
+			w.paths[f.Id] = w.paths[f.Parents[0]] + "/" + f.Name
+			w.pending = append(w.pending, f.Id)
+		}
+		if w.token == "" {
+			w.folders = false
+		}
+		return nil, nil, nil
+	}
+
+	locations := map[string]string{}
+	for _, f := range res.Files {
+		if len(f.Parents) > 0 {
+			locations[f.Id] = w.paths[f.Parents[0]]
+		}
+	}
+	if w.token == "" {
+		w.batch = nil
+	}
+	return res.Files, locations, nil
+}
+
+// SearchSubtree starts searching the current folder and all folders below
+// it. The results stream into a new search listing.
+func (m *gModel) SearchSubtree(ctx context.Context, s parsedSearch) (tea.Cmd, error) {
+	if m.listing.folderId == "" {
+		return nil, fmt.Errorf("in:below: %s is not a folder", m.listing.name)
+	}
+	m.cancelPending()
+
+	l := searchListing(m.searchQuery, s, m.listing.driveId)
+	l.id = m.newListingId()
+	l.name = "Search below " + m.listing.name + ": " + m.searchQuery
+	l.sort = m.sort
+	l.showPaths = false
+	l.subtree = true
+	search := m.typedSearch()
+	l.search = &search
+	l.locations = map[string]string{}
+	l.fetching = true
+	l.loaded = true
+
+	m.searchModel = &l
+	m.isSearching = true
+	m.status = "Query: " + s.query
+
+	return m.stepSubtree(l.id, newSubtreeWalk(ctx, m.listing.folderId, s, m.listing.driveId)), nil
+}
+
+func (m *gModel) stepSubtree(listingId int, w *subtreeWalk) tea.Cmd {
+	c := m.client
+	return func() tea.Msg {
+		hits, locations, err := w.step(c)
+		return subtreeLoadedMsg{listingId: listingId, walk: w, hits: hits, locations: locations, err: err}
+	}
+}
+
+// ShowSubtreeResults adds the results of one step of a walk to its
+// listing and continues the walk, unless the listing was closed.
+func (m *gModel) ShowSubtreeResults(msg subtreeLoadedMsg) tea.Cmd {
+	l := m.listingById(msg.listingId)
+	if l == nil {
+		return nil
+	}
+	if msg.err != nil {
+		l.fetching = false
+		if !errors.Is(msg.err, context.Canceled) {
+			m.err = msg.err
+		}
+		return nil
+	}
+
+	if len(msg.hits) > 0 {
+		selected := l.selected()
+		l.files = append(l.files, msg.hits...)
+		for id, location := range msg.locations {
+			l.locations[id] = location
+		}
+		l.sort.sortFiles(l.files)
+		l.selectFile(selected)
+	}
+
+	if msg.walk.done() {
+		l.fetching = false
+		return nil
+	}
+	return m.stepSubtree(l.id, msg.walk)
+}
@@ -0,0 +1,282 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"drivebrowser/utils"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"google.golang.org/api/drive/v3"
+)
+
+// treeState is the tree mode view of the active listing. Expanded folders
+// are remembered by id, so they stay open across listings and toggles.
+type treeState struct {
+	expanded map[string]bool
+	key      string
+	cursor   int
+	offset   int
+}
+
+// treeRow is one line of the tree. Rows without a file are placeholders
+// for children that are loading, failed or not fetched yet.
+type treeRow struct {
+	file     *drive.File
+	parentId string
+	depth    int
+	top      int
+	note     string
+	more     bool
+}
+
+func (r treeRow) key() string {
+	if r.file == nil {
+		return r.parentId + "/#"
+	}
+	return r.parentId + "/" + r.file.Id
+}
+
+// treeRows flattens the active listing and the cached children of its
+// expanded folders.
+func (m *gModel) treeRows() []treeRow {
+	var rows []treeRow
+
+	var add func(f *drive.File, parentId string, depth int, top int)
+	add = func(f *drive.File, parentId string, depth int, top int) {
+		rows = append(rows, treeRow{file: f, parentId: parentId, depth: depth, top: top})
+		if !isFolder(f) || !m.tree.expanded[f.Id] {
+			return
+		}
+
+		note := treeRow{parentId: f.Id, depth: depth + 1, top: top}
+		children := m.children[f.Id]
+		switch {
+		case children == nil || !children.loaded():
+			note.note = "Loading..."
+		case children.err != nil:
+			note.note = "Error: " + children.err.Error()
+		case len(children.files) == 0:
+			note.note = "(empty folder)"
+		default:
+			for _, child := range children.files {
+				add(child, f.Id, depth+1, top)
+			}
+			switch {
+			case children.fetching:
+				note.note = "Loading..."
+			case children.hasMore():
+				note.note = "… more"
+				note.more = true
+			default:
+				return
+			}
+		}
+		rows = append(rows, note)
+	}
+
+	for i, f := range m.activeListing().files {
+		add(f, "", 0, i)
+	}
+	return rows
+}
+
+// syncTree keeps the tree cursor on the same row while rows are added or
+// removed around it, and the listing cursor on that row's top level entry
+// so further pages are fetched as the tree is scrolled.
+func (m *gModel) syncTree() []treeRow {
+	rows := m.treeRows()
+	current := m.activeListing()
+
+	cursor := -1
+	for i, r := range rows {
+		if r.key() == m.tree.key {
+			cursor = i
+			break
+		}
+	}
+	if cursor < 0 {
+		for i, r := range rows {
+			if r.depth == 0 && r.top == current.cursor {
+				cursor = i
+				break
+			}
+		}
+	}
+	m.moveTree(rows, cursor)
+	return rows
+}
+
+func (m *gModel) moveTree(rows []treeRow, cursor int) {
+	if len(rows) == 0 {
+		m.tree.key = ""
+		m.tree.cursor = 0
+		m.tree.offset = 0
+		return
+	}
+	cursor = max(0, min(cursor, len(rows)-1))
+
+	m.tree.cursor = cursor
+	m.tree.key = rows[cursor].key()
+	m.activeListing().cursor = rows[cursor].top
+}
+
+func (m *gModel) scrollTree(rows []treeRow) {
+	// Reuse the listing viewport logic on a stand-in of the same length.
+	l := listing{files: make([]*drive.File, len(rows)), cursor: m.tree.cursor, offset: m.tree.offset}
+	l.scroll(m.listHeight())
+	m.tree.offset = l.offset
+}
+
+// selectedTreeRow returns the row under the tree cursor.
+func (m *gModel) selectedTreeRow() *treeRow {
+	rows := m.treeRows()
+	if m.tree.cursor < 0 || m.tree.cursor >= len(rows) {
+		return nil
+	}
+	return &rows[m.tree.cursor]
+}
+
+// toggleFolder expands or collapses folder f in place.
+func (m *gModel) toggleFolder(f *drive.File) tea.Cmd {
+	if m.tree.expanded[f.Id] {
+		delete(m.tree.expanded, f.Id)
+		return nil
+	}
+	m.tree.expanded[f.Id] = true
+	return m.FetchChildren(f)
+}
+
+// FetchTree loads the children of expanded folders that are not cached,
+// e.g. after the cache was dropped by a change of sort order.
+func (m *gModel) FetchTree() tea.Cmd {
+	if !m.treeMode || m.locationPicker != nil {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, r := range m.treeRows() {
+		if r.file != nil && m.tree.expanded[r.file.Id] {
+			cmds = append(cmds, m.FetchChildren(r.file))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// treeKey handles the keys that behave differently in tree mode. It
+// reports whether the key was handled.
+func (m *gModel) treeKey(key string) (tea.Cmd, bool) {
+	rows := m.syncTree()
+	moveBy := func(delta int) {
+		l := listing{
+			files:         make([]*drive.File, len(rows)),
+			nextPageToken: m.activeListing().nextPageToken,
+			cursor:        m.tree.cursor,
+		}
+		l.moveCursor(delta)
+		m.moveTree(rows, l.cursor)
+	}
+
+	switch key {
+	case "up", "k":
+		moveBy(-1)
+		return nil, true
+	case "down", "j":
+		moveBy(1)
+		return nil, true
+	case "pgup", "ctrl+u":
+		moveBy(-m.listHeight())
+		return nil, true
+	case "pgdown", "ctrl+d":
+		moveBy(m.listHeight())
+		return nil, true
+	case "home", "g":
+		moveBy(-len(rows))
+		return nil, true
+	case "end", "G":
+		moveBy(len(rows))
+		return nil, true
+	case "enter", "right", "l", "left", "h":
+	default:
+		return nil, false
+	}
+
+	if len(rows) == 0 {
+		return nil, true
+	}
+	r := rows[m.tree.cursor]
+	expand := key == "right" || key == "l"
+
+	switch {
+	case r.more && (key == "enter" || expand):
+		// Stay on the last loaded child; the new page appears below it.
+		m.tree.key = rows[m.tree.cursor-1].key()
+		return m.FetchMoreChildren(r.parentId), true
+
+	case r.file != nil && isFolder(r.file) && key == "enter":
+		return m.toggleFolder(r.file), true
+
+	case r.file != nil && key == "enter":
+		return m.DownloadSelected(), true
+
+	case r.file != nil && isFolder(r.file) && expand:
+		if m.tree.expanded[r.file.Id] {
+			moveBy(1)
+			return nil, true
+		}
+		return m.toggleFolder(r.file), true
+
+	case !expand:
+		if r.file != nil && m.tree.expanded[r.file.Id] {
+			delete(m.tree.expanded, r.file.Id)
+			return nil, true
+		}
+		for i := m.tree.cursor - 1; i >= 0; i-- {
+			if rows[i].depth == r.depth-1 {
+				m.moveTree(rows, i)
+				break
+			}
+		}
+	}
+	return nil, true
+}
+
+// renderTree renders the visible rows of the tree, indented by depth.
+func (m gModel) renderTree() string {
+	rows := m.treeRows()
+	marked := m.markedSet()
+	start := m.tree.offset
+	end := min(start+m.listHeight(), len(rows))
+
+	s := ""
+	for i := start; i < end; i++ {
+		r := rows[i]
+
+		cursor := " "
+		if i == m.tree.cursor {
+			cursor = ">"
+		}
+		indent := strings.Repeat("  ", r.depth)
+
+		if r.file == nil {
+			s += fmt.Sprintf("\n%s   %s   %s", cursor, indent, r.note)
+			continue
+		}
+		if marked[r.file.Id] {
+			cursor += " *"
+		} else {
+			cursor += "  "
+		}
+
+		marker := " "
+		icon := utils.GetFileIcon(r.file.Name, r.file.MimeType)
+		if isFolder(r.file) {
+			marker = "▸"
+			if m.tree.expanded[r.file.Id] {
+				marker = "▾"
+				icon = utils.IconFolderOpen
+			}
+		}
+		s += fmt.Sprintf("\n%s %s%s %s %s", cursor, indent, marker, icon, r.file.Name)
+	}
+	return s
+}